@@ -17,6 +17,7 @@
 package lib
 
 import (
+	"encoding/json"
 	"log"
 	"strings"
 	"time"
@@ -26,6 +27,7 @@ import (
 )
 
 const PIPELINE = "pipeline"
+const SERVING = "serving"
 
 type Pipeline struct {
 	pipeModels.Pipeline
@@ -89,12 +91,37 @@ func (p *Pipeline) ToRequest() *PipelineRequest {
 
 type PipelineRequest engineModels.PipelineRequest
 
+const (
+	WorkloadTypeDeployment  = "deployment"
+	WorkloadTypeStatefulSet = "statefulset"
+	WorkloadTypeDaemonSet   = "daemonset"
+	WorkloadTypeCronJob     = "cronjob"
+	WorkloadTypeJob         = "job"
+	WorkloadTypeContainer   = "container"
+)
+
 type Workload struct {
 	Id          string            `json:"id"`
 	Name        string            `json:"name"`
+	Type        string            `json:"type"`
 	ImageUuid   string            `json:"imageUuid,omitempty"`
 	Environment map[string]string `json:"environment"`
 	Labels      map[string]string `json:"labels"`
+	Cluster     string            `json:"cluster,omitempty"`
+}
+
+// ParseWorkloadId splits a workload id of the form <type>:<namespace>:<name>.
+// ok is false if id is a plain workload name.
+func ParseWorkloadId(id string) (workloadType string, namespace string, name string, ok bool) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 {
+		return "", "", id, false
+	}
+	return strings.ToLower(parts[0]), parts[1], parts[2], true
+}
+
+func NewWorkloadId(workloadType string, namespace string, name string) string {
+	return workloadType + ":" + namespace + ":" + name
 }
 
 type KubeService struct {
@@ -102,7 +129,39 @@ type KubeService struct {
 	BaseType          string   `json:"baseType"`
 	Name              string   `json:"name"`
 	TargetWorkloadIds []string `json:"targetWorkloadIds,omitempty"`
+	Cluster           string   `json:"cluster,omitempty"`
+}
+type OwnershipMatch struct {
+	WorkloadId   string `json:"workloadId"`
+	WorkloadName string `json:"workloadName"`
+	PipelineId   string `json:"pipelineId,omitempty"`
+	Rule         string `json:"rule,omitempty"`
 }
+
+type ServingInstance struct {
+	Id            string `json:"ID"`
+	Name          string `json:"Name"`
+	Description   string `json:"Description"`
+	EntityName    string `json:"EntityName"`
+	ServiceName   string `json:"ServiceName"`
+	Topic         string `json:"Topic"`
+	ApplicationId string `json:"ApplicationId"`
+	UserId        string `json:"UserId"`
+}
+
+type ServingInstancesResponse struct {
+	Total int64             `json:"total"`
+	Count int               `json:"count"`
+	Data  []ServingInstance `json:"data"`
+}
+
+type Flow struct {
+	Id          string `json:"_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	UserId      string `json:"userId"`
+}
+
 type OpenIdToken struct {
 	AccessToken      string    `json:"access_token"`
 	ExpiresIn        float64   `json:"expires_in"`
@@ -112,9 +171,275 @@ type OpenIdToken struct {
 	RequestTime      time.Time `json:"-"`
 }
 
-type DeleteStatus struct {
-	Total     int
-	Remaining int
-	Running   bool
-	Errors    []error
+type ConsumerGroup struct {
+	Id      string `json:"id"`
+	State   string `json:"state"`
+	Members int    `json:"members"`
+}
+
+const (
+	OwnerlessReasonUserNotFound = "user not found"
+	OwnerlessReasonUserDisabled = "user disabled"
+)
+
+// OwnerlessPipeline is a pipeline whose owner was deleted from keycloak or is disabled.
+type OwnerlessPipeline struct {
+	Pipeline pipeModels.Pipeline `json:"pipeline"`
+	Reason   string              `json:"reason"`
+}
+
+// OrphanState tracks since when a resource has been reported as orphaned.
+// Only deletable orphans are removed by bulk deletes.
+type OrphanState struct {
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Scans     int       `json:"scans"`
+	Deletable bool      `json:"deletable"`
+}
+
+type Orphan[T any] struct {
+	Resource T `json:"resource"`
+	OrphanState
+}
+
+const (
+	ResourceKindPipeline      = "pipeline"
+	ResourceKindOwner         = "owner"
+	ResourceKindFlow          = "flow"
+	ResourceKindWorkload      = "workload"
+	ResourceKindService       = "service"
+	ResourceKindTopic         = "topic"
+	ResourceKindConsumerGroup = "consumergroup"
+)
+
+const (
+	ResourceStatusOk = "ok"
+	// ResourceStatusMissing marks a resource or edge target that is referenced but does not exist.
+	ResourceStatusMissing = "missing"
+	// ResourceStatusDangling marks an edge whose source does not exist anymore while its target still does.
+	ResourceStatusDangling = "dangling"
+)
+
+type ResourceNode struct {
+	Key      string `json:"key"`
+	Kind     string `json:"kind"`
+	Id       string `json:"id"`
+	Status   string `json:"status"`
+	Resource any    `json:"resource,omitempty"`
+}
+
+type ResourceEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ResourceGraph holds everything that belongs to one pipeline. Node keys have the form <kind>/<id>.
+type ResourceGraph struct {
+	PipelineId string         `json:"pipelineId"`
+	Nodes      []ResourceNode `json:"nodes"`
+	Edges      []ResourceEdge `json:"edges"`
+}
+
+// AddNode adds a node unless a node with the same key exists and returns its key.
+func (g *ResourceGraph) AddNode(kind string, id string, status string, resource any) string {
+	key := kind + "/" + id
+	if !g.HasNode(key) {
+		g.Nodes = append(g.Nodes, ResourceNode{Key: key, Kind: kind, Id: id, Status: status, Resource: resource})
+	}
+	return key
+}
+
+func (g *ResourceGraph) AddEdge(from string, to string, status string, reason string) {
+	g.Edges = append(g.Edges, ResourceEdge{From: from, To: to, Status: status, Reason: reason})
+}
+
+func (g *ResourceGraph) HasNode(key string) bool {
+	for _, node := range g.Nodes {
+		if node.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	DeleteResultDeleted     = "deleted"
+	DeleteResultNotFound    = "not found"
+	DeleteResultFailed      = "failed"
+	DeleteResultWouldDelete = "would delete"
+	// DeleteResultIndeterminate marks deletes that were canceled in flight, the resource may be gone or not
+	DeleteResultIndeterminate = "indeterminate"
+)
+
+// DeleteResult is the outcome of one delete. Reason explains why the resource was selected, dry runs report
+// DeleteResultWouldDelete instead of deleting.
+type DeleteResult struct {
+	Kind   string `json:"kind"`
+	Id     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type PipelineDeleteReport struct {
+	PipelineId string         `json:"pipelineId"`
+	Name       string         `json:"name"`
+	Results    []DeleteResult `json:"results"`
+}
+
+// OffboardingReport summarizes what an offboarding job deleted for a user. Signature is the hex encoded HMAC-SHA256
+// of the JSON encoded report with an empty signature. Dry run reports are not stored.
+type OffboardingReport struct {
+	UserId     string                 `json:"userId"`
+	StartedAt  time.Time              `json:"startedAt"`
+	FinishedAt time.Time              `json:"finishedAt"`
+	Pipelines  []PipelineDeleteReport `json:"pipelines"`
+	Complete   bool                   `json:"complete"`
+	DryRun     bool                   `json:"dryRun,omitempty"`
+	Signature  string                 `json:"signature"`
+}
+
+type RecreateRequest struct {
+	UserId      string   `json:"userId,omitempty"`
+	PipelineIds []string `json:"pipelineIds,omitempty"`
+}
+
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCanceled  = "canceled"
+	// JobStatusInterrupted marks jobs that were running when the service stopped.
+	JobStatusInterrupted = "interrupted"
+)
+
+const (
+	JobResultRecreated = "recreated"
+	JobResultSkipped   = "skipped"
+	// JobResultStored marks a stored offboarding report, Reason is the name of its file.
+	JobResultStored = "stored"
+)
+
+// JobItemKindReport is the last item of an offboarding job, it signs and stores the report of the user.
+const JobItemKindReport = "report"
+
+// JobItem is a resource a job still has to process.
+type JobItem struct {
+	Kind string `json:"kind"`
+	Id   string `json:"id"`
+}
+
+// JobResult is the outcome of one item of a job. Status is one of the DeleteResult or JobResult statuses.
+type JobResult struct {
+	Kind   string `json:"kind"`
+	Id     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Job is a bulk operation running in the background. Failed counts the results with status failed.
+// Pending and Results are left out in job listings. Dry runs are processed right away and not stored.
+type Job struct {
+	Id         string      `json:"id"`
+	Type       string      `json:"type"`
+	UserId     string      `json:"userId"`
+	Status     string      `json:"status"`
+	Total      int         `json:"total"`
+	Processed  int         `json:"processed"`
+	Failed     int         `json:"failed"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+	Pending    []JobItem   `json:"pending,omitempty"`
+	Results    []JobResult `json:"results,omitempty"`
+	DryRun     bool        `json:"dryRun,omitempty"`
+}
+
+// OrphanEntry is an orphan of any kind, identified by the key it is tracked and deleted with.
+type OrphanEntry struct {
+	Kind string `json:"kind"`
+	Id   string `json:"id"`
+	OrphanState
+}
+
+type ScheduledTaskResult struct {
+	Kind      string `json:"kind"`
+	Delete    bool   `json:"delete"`
+	Found     int    `json:"found"`
+	Deletable int    `json:"deletable"`
+	Deleted   int    `json:"deleted"`
+	Error     string `json:"error,omitempty"`
+}
+
+type SchedulerStatus struct {
+	Schedule     string                `json:"schedule"`
+	Running      bool                  `json:"running"`
+	LastRun      *time.Time            `json:"lastRun,omitempty"`
+	LastFinished *time.Time            `json:"lastFinished,omitempty"`
+	NextRun      *time.Time            `json:"nextRun,omitempty"`
+	Results      []ScheduledTaskResult `json:"results"`
+}
+
+type PlanRequest struct {
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+const (
+	PlanStatusPending  = "pending"
+	PlanStatusApproved = "approved"
+	PlanStatusRejected = "rejected"
+	PlanStatusExpired  = "expired"
+	PlanStatusApplied  = "applied"
+)
+
+// PlanDecision records who approved or rejected a plan and when.
+type PlanDecision struct {
+	UserId string    `json:"userId"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Plan is a snapshot of the deletable orphans of some kinds. Applying it deletes only these items.
+// Hash is the hex encoded SHA-256 of the kinds and item keys. Plans with RequiredApprovals stay pending until
+// that many admins other than the creator approved them.
+type Plan struct {
+	Id                string         `json:"id"`
+	Hash              string         `json:"hash"`
+	UserId            string         `json:"userId"`
+	Status            string         `json:"status"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	ExpiresAt         *time.Time     `json:"expiresAt,omitempty"`
+	Kinds             []string       `json:"kinds"`
+	Items             []OrphanEntry  `json:"items,omitempty"`
+	RequiredApprovals int            `json:"requiredApprovals"`
+	Approvals         []PlanDecision `json:"approvals"`
+	Rejection         *PlanDecision  `json:"rejection,omitempty"`
+	ExpiredAt         *time.Time     `json:"expiredAt,omitempty"`
+	AppliedAt         *time.Time     `json:"appliedAt,omitempty"`
+	JobId             string         `json:"jobId,omitempty"`
+}
+
+// TopicSpec records the layout and the topic specific configs of a kafka topic. Partitions maps each partition
+// to its replica brokers.
+type TopicSpec struct {
+	Name              string            `json:"name"`
+	ReplicationFactor int16             `json:"replicationFactor"`
+	Partitions        map[int32][]int32 `json:"partitions"`
+	Config            map[string]string `json:"config,omitempty"`
+}
+
+// QuarantineEntry keeps what is needed to restore a deleted resource until PurgeAt. Spec is the driver export of
+// workloads and services, the pipeline definition of pipelines or the TopicSpec of topics.
+type QuarantineEntry struct {
+	Id            string          `json:"id"`
+	Kind          string          `json:"kind"`
+	ResourceId    string          `json:"resourceId"`
+	Collection    string          `json:"collection,omitempty"`
+	Spec          json.RawMessage `json:"spec"`
+	QuarantinedAt time.Time       `json:"quarantinedAt"`
+	PurgeAt       time.Time       `json:"purgeAt"`
+	RestoredAt    *time.Time      `json:"restoredAt,omitempty"`
+	RestoredBy    string          `json:"restoredBy,omitempty"`
 }
@@ -40,6 +40,12 @@ type ConflictError struct {
 	cError
 }
 
+// IndeterminateError is returned for calls whose outcome is unknown, e.g. deletes that were still in flight when they
+// were canceled.
+type IndeterminateError struct {
+	cError
+}
+
 func (e *cError) Error() string {
 	return e.err.Error()
 }
@@ -67,3 +73,7 @@ func NewForbiddenError(err error) error {
 func NewConflictError(err error) error {
 	return &ConflictError{cError{err: err}}
 }
+
+func NewIndeterminateError(err error) error {
+	return &IndeterminateError{cError{err: err}}
+}
@@ -20,16 +20,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/SENERGY-Platform/analytics-cleanup/pkg/api"
 	"github.com/SENERGY-Platform/analytics-cleanup/pkg/apis"
+	docker_api "github.com/SENERGY-Platform/analytics-cleanup/pkg/apis/docker-api"
+	kubernetes_api "github.com/SENERGY-Platform/analytics-cleanup/pkg/apis/kubernetes-api"
 	rancher2_api "github.com/SENERGY-Platform/analytics-cleanup/pkg/apis/rancher2-api"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/cli"
 	"github.com/SENERGY-Platform/analytics-cleanup/pkg/config"
 	"github.com/SENERGY-Platform/analytics-cleanup/pkg/service"
 	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
@@ -37,14 +43,16 @@ import (
 	sb_util "github.com/SENERGY-Platform/go-service-base/util"
 
 	"github.com/SENERGY-Platform/go-service-base/srv-info-hdl"
+	"github.com/gin-gonic/gin"
 )
 
 var Version = "{version}"
 
 func main() {
-	ec := 0
+	// written by the goroutines below as well
+	var ec atomic.Int32
 	defer func() {
-		os.Exit(ec)
+		os.Exit(int(ec.Load()))
 	}()
 
 	srvInfoHdl := srv_info_hdl.New("cleanup-service", Version)
@@ -54,35 +62,104 @@ func main() {
 	cfg, err := config.New(config.ConfPath)
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
-		ec = 1
+		ec.Store(1)
 		return
 	}
 
-	util.InitStructLogger(cfg.Logger.Level)
+	// keep stdout free for the command output in cli mode
+	logOut := os.Stdout
+	if cfg.Mode == "cli" {
+		logOut = os.Stderr
+	}
+	util.InitStructLogger(cfg.Logger.Level, logOut)
 
 	util.Logger.Info(srvInfoHdl.Name(), "version", srvInfoHdl.Version())
 	util.Logger.Info("config: " + sb_util.ToJsonStr(cfg))
+	if cfg.DryRun {
+		util.Logger.Warn("dry run enabled, nothing will be deleted")
+	}
+
+	switch cfg.Mode {
+	case "web":
+	case "cron":
+		if cfg.CronSchedule == "" {
+			util.Logger.Error("cron mode requires a cron schedule")
+			ec.Store(1)
+			return
+		}
+	case "cli":
+		if config.Command == "" {
+			util.Logger.Error("cli mode requires a command")
+			ec.Store(1)
+			return
+		}
+	default:
+		util.Logger.Error("unknown mode", "mode", cfg.Mode)
+		ec.Store(1)
+		return
+	}
+	if config.Command != "" && cfg.Mode != "cli" {
+		util.Logger.Error("commands require cli mode", "mode", cfg.Mode)
+		ec.Store(1)
+		return
+	}
 
 	pipeline := apis.NewPipelineService(
 		cfg.PipelineApiEndpoint,
 		cfg.FlowEngineApiEndpoint,
+		cfg.HttpTimeout,
 	)
 
+	serving := apis.NewServingService(cfg.ServingApiEndpoint, cfg.HttpTimeout)
+
+	flowRepo := apis.NewFlowRepoService(cfg.FlowRepoApiEndpoint, cfg.HttpTimeout)
+
 	var driver service.Driver
-	switch selectedDriver := cfg.Driver; selectedDriver {
-	case "rancher2":
-		driver = rancher2_api.NewRancher2(
-			cfg.Rancher2Config.Endpoint,
-			cfg.Rancher2Config.AccessKey,
-			cfg.Rancher2Config.SecretKey,
-			"",
-			cfg.Rancher2Config.ServingProjectId,
-			cfg.Rancher2Config.PipelineNamespaceId,
-			cfg.Rancher2Config.PipelineProjectId,
-		)
-	default:
-		panic("No driver selected")
+	if cfg.Driver == "multi" {
+		var clusters []service.Cluster
+		for _, clusterCfg := range cfg.Clusters {
+			var clusterDriver service.Driver
+			clusterDriver, err = newDriver(clusterCfg, cfg.HttpTimeout)
+			if err != nil {
+				util.Logger.Error("error creating driver", "cluster", clusterCfg.Name, "error", err)
+				ec.Store(1)
+				return
+			}
+			clusters = append(clusters, service.Cluster{Name: clusterCfg.Name, Driver: clusterDriver})
+		}
+		driver, err = service.NewMultiClusterDriver(clusters)
+		if err != nil {
+			util.Logger.Error("invalid cluster config", "error", err)
+			ec.Store(1)
+			return
+		}
+	} else {
+		driver, err = newDriver(config.ClusterConfig{
+			Driver:           cfg.Driver,
+			Rancher2Config:   cfg.Rancher2Config,
+			KubernetesConfig: cfg.KubernetesConfig,
+			DockerConfig:     cfg.DockerConfig,
+		}, cfg.HttpTimeout)
+		if err != nil {
+			util.Logger.Error("error creating driver", "error", err)
+			ec.Store(1)
+			return
+		}
+	}
+	ownership, err := service.NewOwnershipMatcher(cfg.OwnershipRules)
+	if err != nil {
+		util.Logger.Error("error parsing ownership rules", "error", err)
+		ec.Store(1)
+		return
 	}
+
+	tracker, err := service.NewOrphanTracker(filepath.Join(cfg.DataDir, "orphans.json"), cfg.OrphanGracePeriod, cfg.OrphanMinScans, cfg.OrphanScanInterval)
+	if err != nil {
+		util.Logger.Error("error loading orphan state", "error", err)
+		ec.Store(1)
+		return
+	}
+
 	keycloak := apis.NewKeycloakService(
 		cfg.Keycloak.Url,
 		cfg.Keycloak.ClientId,
@@ -90,6 +167,7 @@ func main() {
 		cfg.Keycloak.Realm,
 		cfg.Keycloak.User,
 		cfg.Keycloak.Password,
+		cfg.HttpTimeout,
 	)
 
 	ctx, cf := context.WithCancel(context.Background())
@@ -97,63 +175,105 @@ func main() {
 	kafkaAdmin, err := apis.NewKafkaAdmin(cfg.KafkaBootstrap)
 	if err != nil {
 		util.Logger.Error("error creating kafka admin", "error", err)
-		ec = 1
+		ec.Store(1)
 		return
 	}
 
 	fileLogger := util.NewFileLogger("logs/cleanup.log", "")
 	defer fileLogger.Close()
-	serv := service.NewCleanupService(*keycloak, driver, *pipeline, *fileLogger, kafkaAdmin, ctx)
+	jobs, err := service.NewJobManager(ctx, filepath.Join(cfg.DataDir, "jobs"), cfg.JobHistory)
+	if err != nil {
+		util.Logger.Error("error loading jobs", "error", err)
+		ec.Store(1)
+		return
+	}
+	serv := service.NewCleanupService(cfg, *keycloak, driver, *pipeline, *flowRepo, *serving, ownership, tracker, jobs, service.NewPlanStore(filepath.Join(cfg.DataDir, "plans")), service.NewQuarantineStore(filepath.Join(cfg.DataDir, "quarantine")), *fileLogger, kafkaAdmin, ctx)
 
-	httpHandler, err := api.CreateServer(cfg, serv)
+	var httpServer *http.Server
+	switch cfg.Mode {
+	case "web":
+		httpServer, err = newHttpServer(cfg, serv, api.CreateServer, ctx)
+	case "cron":
+		// no api in cron mode, but probes and the results of the scheduled runs need a server
+		httpServer, err = newHttpServer(cfg, serv, api.CreateStatusServer, ctx)
+	}
 	if err != nil {
 		util.Logger.Error("error creating http engine", "error", err)
-		ec = 1
+		ec.Store(1)
 		return
 	}
-	bindAddress := ":" + strconv.FormatInt(int64(cfg.ServerPort), 10)
-	if cfg.Debug {
-		bindAddress = "127.0.0.1:" + strconv.FormatInt(int64(cfg.ServerPort), 10)
-	}
-	httpServer := &http.Server{
-		Addr:    bindAddress,
-		Handler: httpHandler}
 
 	go func() {
 		util.Wait(ctx, util.Logger, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 		cf()
 	}()
 
-	wg := &sync.WaitGroup{}
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		util.Logger.Info("starting http server")
-		if err = httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-			util.Logger.Error("starting server failed", attributes.ErrorKey, err)
-			ec = 1
+	if cfg.Mode == "cli" {
+		if err = cli.Run(ctx, serv, config.Command, config.CommandArgs, os.Stdout); err != nil {
+			util.Logger.Error("command failed", attributes.ErrorKey, err)
+			ec.Store(1)
 		}
 		cf()
-	}()
+		if err = kafkaAdmin.Close(); err != nil {
+			util.Logger.Error("stopping kafka client failed", attributes.ErrorKey, err)
+			ec.Store(1)
+		}
+		return
+	}
+
+	if cfg.ResumeInterruptedJobs {
+		go serv.ResumeInterruptedJobs(ctx)
+	}
+
+	// entries are purged even with quarantine disabled, they may remain from before
+	go serv.RunQuarantinePurge(ctx)
+
+	wg := &sync.WaitGroup{}
+
+	if cfg.CronSchedule != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := serv.RunScheduler(ctx); err != nil {
+				util.Logger.Error("scheduler failed", attributes.ErrorKey, err)
+				ec.Store(1)
+			}
+			cf()
+		}()
+	}
+
+	if httpServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			util.Logger.Info("starting http server")
+			if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				util.Logger.Error("starting server failed", attributes.ErrorKey, err)
+				ec.Store(1)
+			}
+			cf()
+		}()
+	}
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		<-ctx.Done()
-		util.Logger.Info("stopping http server")
-		ctxWt, cf2 := context.WithTimeout(context.Background(), time.Second*5)
-		defer cf2()
-		if err = httpServer.Shutdown(ctxWt); err != nil {
-			util.Logger.Error("stopping server failed", attributes.ErrorKey, err)
-			ec = 1
-		} else {
-			util.Logger.Info("http server stopped")
+		if httpServer != nil {
+			util.Logger.Info("stopping http server")
+			ctxWt, cf2 := context.WithTimeout(context.Background(), time.Second*5)
+			defer cf2()
+			if err := httpServer.Shutdown(ctxWt); err != nil {
+				util.Logger.Error("stopping server failed", attributes.ErrorKey, err)
+				ec.Store(1)
+			} else {
+				util.Logger.Info("http server stopped")
+			}
 		}
 		util.Logger.Info("stopping kafka client")
-		if err = kafkaAdmin.Close(); err != nil {
+		if err := kafkaAdmin.Close(); err != nil {
 			util.Logger.Error("stopping kafka client failed", attributes.ErrorKey, err)
-			ec = 1
+			ec.Store(1)
 		} else {
 			util.Logger.Info("kafka client stopped")
 		}
@@ -161,3 +281,58 @@ func main() {
 
 	wg.Wait()
 }
+
+func newHttpServer(cfg *config.Config, serv *service.CleanupService, createServer func(*config.Config, *service.CleanupService) (*gin.Engine, error), ctx context.Context) (*http.Server, error) {
+	httpHandler, err := createServer(cfg, serv)
+	if err != nil {
+		return nil, err
+	}
+	bindAddress := ":" + strconv.FormatInt(int64(cfg.ServerPort), 10)
+	if cfg.Debug {
+		bindAddress = "127.0.0.1:" + strconv.FormatInt(int64(cfg.ServerPort), 10)
+	}
+	return &http.Server{
+		Addr:    bindAddress,
+		Handler: httpHandler,
+		// request contexts are cancelled on shutdown, which aborts in-flight backend calls
+		BaseContext: func(_ net.Listener) context.Context {
+			return ctx
+		},
+	}, nil
+}
+
+func newDriver(cfg config.ClusterConfig, timeout time.Duration) (service.Driver, error) {
+	switch cfg.Driver {
+	case "rancher2":
+		return rancher2_api.NewRancher2(
+			cfg.Rancher2Config.Endpoint,
+			cfg.Rancher2Config.AccessKey,
+			cfg.Rancher2Config.SecretKey,
+			cfg.Rancher2Config.ServingNamespaceId,
+			cfg.Rancher2Config.ServingProjectId,
+			cfg.Rancher2Config.PipelineNamespaceId,
+			cfg.Rancher2Config.PipelineProjectId,
+			timeout,
+		), nil
+	case "kubernetes":
+		restConfig, err := kubernetes_api.GetRestConfig(cfg.KubernetesConfig.Kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		restConfig.Timeout = timeout
+		return kubernetes_api.NewKubernetes(
+			restConfig,
+			cfg.KubernetesConfig.ServingNamespace,
+			cfg.KubernetesConfig.PipelineNamespace,
+		)
+	case "docker":
+		return docker_api.NewDocker(
+			cfg.DockerConfig.Host,
+			cfg.DockerConfig.ServingProject,
+			cfg.DockerConfig.PipelineProject,
+			timeout,
+		)
+	default:
+		return nil, errors.New("unknown driver: " + cfg.Driver)
+	}
+}
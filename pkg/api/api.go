@@ -17,6 +17,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"slices"
 	"strconv"
@@ -42,6 +43,15 @@ import (
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 // @BasePath /
 func CreateServer(cfg *config.Config, cs *service.CleanupService) (r *gin.Engine, err error) {
+	return newEngine(cfg, cs, routes, routesAuth)
+}
+
+// CreateStatusServer creates the server of the cron mode, it only serves the health check and the scheduler status.
+func CreateStatusServer(cfg *config.Config, cs *service.CleanupService) (r *gin.Engine, err error) {
+	return newEngine(cfg, cs, routesStatus, routesStatusAuth)
+}
+
+func newEngine(cfg *config.Config, cs *service.CleanupService, routes gin_mw.Routes[*service.CleanupService], routesAuth gin_mw.Routes[*service.CleanupService]) (r *gin.Engine, err error) {
 	port := strconv.FormatInt(int64(cfg.ServerPort), 10)
 	util.Logger.Info("Starting api server at port " + port)
 	if !cfg.Debug {
@@ -94,7 +104,7 @@ func CreateServer(cfg *config.Config, cs *service.CleanupService) (r *gin.Engine
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		admin, err := isAdmin(c)
+		admin, userId, err := isAdmin(c)
 		if err != nil {
 			util.Logger.Error("could not check admin role", "error", err)
 			c.AbortWithStatus(http.StatusForbidden)
@@ -105,18 +115,26 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatus(http.StatusForbidden)
 			return
 		}
+		c.Set(UserIdKey, userId)
 		c.Next()
 	}
 }
 
-func isAdmin(c *gin.Context) (result bool, err error) {
+// isAdmin checks the roles of the caller and returns the subject of its token as user id. Callers without a user id
+// are rejected, deletes and plans are attributed to it.
+func isAdmin(c *gin.Context) (result bool, userId string, err error) {
 	rolesHeader := c.GetHeader("X-User-Roles")
 	if rolesHeader != "" {
 		roles := strings.Split(rolesHeader, ", ")
-		if slices.Contains[[]string](roles, "admin") {
-			return true, nil
+		// the roles were checked by the gateway, the token is only needed for the user id
+		claims, err := jwt.Parse(c.GetHeader("Authorization"))
+		if err != nil {
+			return false, "", err
+		}
+		if claims.GetUserId() == "" {
+			return false, "", errors.New("token has no user id")
 		}
-		return false, nil
+		return slices.Contains[[]string](roles, "admin"), claims.GetUserId(), nil
 	}
 	if c.GetHeader("Authorization") != "" {
 		var claims jwt.Token
@@ -124,7 +142,10 @@ func isAdmin(c *gin.Context) (result bool, err error) {
 		if err != nil {
 			return
 		}
-		return claims.IsAdmin(), nil
+		if claims.GetUserId() == "" {
+			return false, "", errors.New("token has no user id")
+		}
+		return claims.IsAdmin(), claims.GetUserId(), nil
 	}
-	return false, nil
+	return false, "", nil
 }
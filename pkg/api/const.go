@@ -30,5 +30,5 @@ const (
 	MessageSomethingWrong = "something went wrong"
 	MessageNotFound       = "not found"
 	MessageForbidden      = "forbidden"
-	MessageConflict       = "already running"
+	MessageBadRequest     = "bad request"
 )
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/SENERGY-Platform/analytics-cleanup/lib"
 	"github.com/SENERGY-Platform/analytics-cleanup/pkg/service"
@@ -32,13 +33,13 @@ import (
 // @Description	Gets all orphaned pipe services
 // @Tags pipeline-services
 // @Produce json
-// @Success	200 {array} lib.Pipeline
+// @Success	200 {array} lib.Orphan[lib.Pipeline]
 // @Failure 403 {string} string "forbidden"
 // @Failure 500 {string} string "something went wrong"
 // @Router /pipeservices [get]
 func getOrphanedPipelineServices(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodGet, "/pipeservices", func(c *gin.Context) {
-		pipes, err := service.GetOrphanedPipelineServices(c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		pipes, err := service.GetOrphanedPipelineServices(c.Request.Context(), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
 		if err != nil {
 			util.Logger.Error("could not get OrphanedPipelineServices", "error", err)
 			_ = c.Error(errors.New(MessageSomethingWrong))
@@ -52,41 +53,327 @@ func getOrphanedPipelineServices(service *service.CleanupService) (string, strin
 // @Summary Delete orphaned pipeline service
 // @Description Deletes an orphaned pipeline service by ID
 // @Tags pipeline-services
+// @Produce json
 // @Param   id path string true "Pipeline Service ID"
+// @Param dryRun query bool false "Only report what would be deleted"
 // @Success 204
+// @Success 200 {object} lib.DeleteResult "dry run"
+// @Failure 400 {string} string "bad request"
 // @Failure 403 {string} string "forbidden"
 // @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "not orphaned"
 // @Failure 500 {string} string "something went wrong"
 // @Router /pipeservices/{id} [delete]
 func deleteOrphanedPipelineService(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodDelete, "/pipeservices/:id", func(c *gin.Context) {
-		err := service.DeleteOrphanedPipelineService(c.Param("id"), c.GetHeader(HeaderAuth)[7:])
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		result, err := service.DeleteOrphan(ctx, kindPipelines, lib.ResourceKindPipeline, c.Param("id"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
 		if err != nil {
 			util.Logger.Error("could not delete OrphanedPipelineService", "error", err)
 			_ = c.Error(handleError(err))
 			return
 		}
-		c.Status(http.StatusNoContent)
+		writeDeleteResult(c, result)
 	}
 }
 
 // deleteOrphanedPipelineServices godoc
 // @Summary Delete orphaned pipeline services
-// @Description Deletes all orphaned pipeline services
+// @Description Starts a job deleting all orphaned pipeline services that passed the orphan grace period
 // @Tags pipeline-services
-// @Success 204
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
 // @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
 // @Failure 500 {string} string "something went wrong"
 // @Router /pipeservices [delete]
 func deleteOrphanedPipelineServices(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodDelete, "/pipeservices", func(c *gin.Context) {
-		_, err := service.DeleteOrphanedPipelineServices(c.Param("id"), c.GetHeader(HeaderAuth)[7:])
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOrphanedPipelineServices(ctx, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
 		if err != nil {
 			util.Logger.Error("could not delete OrphanedPipelineServices", "error", err)
 			_ = c.Error(handleError(err))
 			return
 		}
-		c.Status(http.StatusNotFound)
+		c.JSON(jobStatus(job), job)
+	}
+}
+
+// getOwnerlessPipelines godoc
+// @Summary Get all ownerless pipelines
+// @Description	Gets all pipelines whose owner no longer exists in keycloak or is disabled
+// @Tags pipelines
+// @Produce json
+// @Success	200 {array} lib.Orphan[lib.OwnerlessPipeline]
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /pipelines/ownerless [get]
+func getOwnerlessPipelines(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/pipelines/ownerless", func(c *gin.Context) {
+		pipes, err := service.GetOwnerlessPipelines(c.Request.Context(), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not get OwnerlessPipelines", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, pipes)
+	}
+}
+
+// deleteOwnerlessPipelines godoc
+// @Summary Delete ownerless pipelines
+// @Description Starts a job deleting all ownerless pipelines that passed the orphan grace period together with their workloads, services and kafka topics
+// @Tags pipelines
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
+// @Failure 500 {string} string "something went wrong"
+// @Router /pipelines/ownerless [delete]
+func deleteOwnerlessPipelines(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/pipelines/ownerless", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOwnerlessPipelines(ctx, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete OwnerlessPipelines", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(jobStatus(job), job)
+	}
+}
+
+// getPipelinesWithDanglingFlows godoc
+// @Summary Get all pipelines with dangling flows
+// @Description	Gets all pipelines whose flow no longer exists in the flow repository
+// @Tags pipelines
+// @Produce json
+// @Success	200 {array} lib.Orphan[lib.Pipeline]
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /pipelines/danglingflows [get]
+func getPipelinesWithDanglingFlows(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/pipelines/danglingflows", func(c *gin.Context) {
+		pipes, err := service.GetPipelinesWithDanglingFlows(c.Request.Context(), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not get PipelinesWithDanglingFlows", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, pipes)
+	}
+}
+
+// getPipelineResources godoc
+// @Summary Get pipeline resources
+// @Description	Gets the registry entry, owner, flow, workloads, services, topics and consumer groups of a pipeline. Edges are marked missing if a referenced resource does not exist and dangling if a resource outlived the pipeline.
+// @Tags pipelines
+// @Produce json
+// @Param id path string true "Pipeline ID"
+// @Success	200 {object} lib.ResourceGraph
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "something went wrong"
+// @Router /pipelines/{id}/resources [get]
+func getPipelineResources(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/pipelines/:id/resources", func(c *gin.Context) {
+		graph, err := service.GetPipelineResources(c.Request.Context(), c.Param("id"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not get PipelineResources", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, graph)
+	}
+}
+
+// deletePipeline godoc
+// @Summary Delete pipeline
+// @Description Deletes a pipeline from the registry. With cascade, its workloads, services, topics and consumer groups are deleted as well. Cascade requires the pipeline to be in the registry or the id to be a pipeline uuid, workloads are only matched by label or env. All resources are attempted, partial failures are reported with status 207.
+// @Tags pipelines
+// @Produce json
+// @Param id path string true "Pipeline ID"
+// @Param cascade query bool false "delete all resources of the pipeline"
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 200 {array} lib.DeleteResult
+// @Success 207 {array} lib.DeleteResult
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "something went wrong"
+// @Router /pipelines/{id} [delete]
+func deletePipeline(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/pipelines/:id", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		cascade, err := strconv.ParseBool(c.DefaultQuery("cascade", "false"))
+		if err != nil {
+			_ = c.Error(lib.NewInputError(err))
+			return
+		}
+		results, err := service.DeletePipeline(ctx, c.Param("id"), cascade, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete Pipeline", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		status := http.StatusOK
+		for _, result := range results {
+			if result.Status == lib.DeleteResultFailed {
+				status = http.StatusMultiStatus
+				break
+			}
+		}
+		c.JSON(status, results)
+	}
+}
+
+// deleteUserResources godoc
+// @Summary Delete user resources
+// @Description Starts a job deleting all pipelines of a user with their workloads, services, topics and consumer groups. The last item of the job stores a signed report, see the reports of the user. Dry runs are returned finished with status 200 and store no report.
+// @Tags users
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 200 {object} lib.Job
+// @Success 202 {object} lib.Job
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
+// @Failure 500 {string} string "something went wrong"
+// @Router /users/{userId}/resources [delete]
+func deleteUserResources(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/users/:userId/resources", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteUserResources(ctx, c.Param("userId"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete UserResources", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(jobStatus(job), job)
+	}
+}
+
+// getOffboardingReports godoc
+// @Summary Get offboarding reports
+// @Description Get the signed reports of the offboarding jobs of a user, oldest first
+// @Tags users
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {array} lib.OffboardingReport
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /users/{userId}/reports [get]
+func getOffboardingReports(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/users/:userId/reports", func(c *gin.Context) {
+		reports, err := service.GetOffboardingReports(c.Param("userId"))
+		if err != nil {
+			util.Logger.Error("could not get OffboardingReports", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, reports)
+	}
+}
+
+// recreatePipelines godoc
+// @Summary Recreate pipelines
+// @Description Starts a job recreating pipelines whose workloads vanished, optionally limited to a user or a set of pipeline IDs
+// @Tags pipelines
+// @Accept json
+// @Param request body lib.RecreateRequest false "Limit the recreation"
+// @Produce json
+// @Success 202 {object} lib.Job
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
+// @Failure 500 {string} string "something went wrong"
+// @Router /pipelines/recreate [post]
+func recreatePipelines(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/pipelines/recreate", func(c *gin.Context) {
+		var request lib.RecreateRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				_ = c.Error(lib.NewInputError(err))
+				return
+			}
+		}
+		job, err := service.RecreatePipelines(c.Request.Context(), request, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not recreate Pipelines", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+// getRecreatePipelinesStatus godoc
+// @Summary Get pipeline recreation status
+// @Description Get the progress and the per pipeline outcomes of the pipeline recreation
+// @Tags pipelines
+// @Produce json
+// @Success 200 {object} lib.Job
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "something went wrong"
+// @Router /pipelines/recreate/status [get]
+func getRecreatePipelinesStatus(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/pipelines/recreate/status", func(c *gin.Context) {
+		job, err := service.GetRecreatePipelinesStatus()
+		if err != nil {
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// stopRecreatePipelines godoc
+// @Summary Stop pipeline recreation
+// @Description Stops the pipeline recreation
+// @Tags pipelines
+// @Success 200
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /pipelines/recreate/stop [post]
+func stopRecreatePipelines(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/pipelines/recreate/stop", func(c *gin.Context) {
+		err := service.StopRecreatePipelines()
+		if err != nil {
+			util.Logger.Error("could not stop the recreation of Pipelines", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.Status(http.StatusOK)
 	}
 }
 
@@ -95,13 +382,13 @@ func deleteOrphanedPipelineServices(service *service.CleanupService) (string, st
 // @Description	Gets all orphaned workloads
 // @Tags workloads
 // @Produce json
-// @Success	200 {array} lib.Workload
+// @Success	200 {array} lib.Orphan[lib.Workload]
 // @Failure 403 {string} string "forbidden"
 // @Failure 500 {string} string "something went wrong"
 // @Router /analyticsworkloads [get]
 func getOrphanedAnalyticsWorkloads(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodGet, "/analyticsworkloads", func(c *gin.Context) {
-		wls, err := service.GetOrphanedAnalyticsWorkloads(c.Param("id"), c.GetHeader(HeaderAuth)[7:])
+		wls, err := service.GetOrphanedAnalyticsWorkloads(c.Request.Context(), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
 		if err != nil {
 			util.Logger.Error("could not get OrphanedAnalyticsWorkloads", "error", err)
 			_ = c.Error(errors.New(MessageSomethingWrong))
@@ -111,45 +398,86 @@ func getOrphanedAnalyticsWorkloads(service *service.CleanupService) (string, str
 	}
 }
 
+// getAnalyticsWorkloadOwnership godoc
+// @Summary Get workload ownership
+// @Description	Gets the owning pipeline of every analytics workload and the rule that matched it
+// @Tags workloads
+// @Produce json
+// @Success	200 {array} lib.OwnershipMatch
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /analyticsworkloads/ownership [get]
+func getAnalyticsWorkloadOwnership(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/analyticsworkloads/ownership", func(c *gin.Context) {
+		matches, err := service.GetAnalyticsWorkloadOwnership(c.Request.Context(), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not get AnalyticsWorkloadOwnership", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, matches)
+	}
+}
+
 // deleteOrphanedAnalyticsWorkload godoc
 // @Summary Delete orphaned workload
 // @Description Deletes an orphaned workload by name
 // @Tags workloads
+// @Produce json
 // @Param   name path string true "Workload name"
+// @Param dryRun query bool false "Only report what would be deleted"
 // @Success 204
+// @Success 200 {object} lib.DeleteResult "dry run"
+// @Failure 400 {string} string "bad request"
 // @Failure 403 {string} string "forbidden"
 // @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "not orphaned"
 // @Failure 500 {string} string "something went wrong"
 // @Router /analyticsworkloads/{name} [delete]
 func deleteOrphanedAnalyticsWorkload(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodDelete, "/analyticsworkloads/:name", func(c *gin.Context) {
-		err := service.DeleteOrphanedAnalyticsWorkload(c.Param("name"))
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		result, err := service.DeleteOrphan(ctx, kindAnalyticsWorkloads, lib.ResourceKindWorkload, c.Param("name"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
 		if err != nil {
 			util.Logger.Error("could not delete OrphanedAnalyticsWorkload", "error", err)
 			_ = c.Error(handleError(err))
 			return
 		}
-		c.Status(http.StatusNoContent)
+		writeDeleteResult(c, result)
 	}
 }
 
 // deleteOrphanedAnalyticsWorkloads godoc
 // @Summary Delete orphaned workloads
-// @Description Deletes all orphaned workloads
+// @Description Starts a job deleting all orphaned workloads that passed the orphan grace period
 // @Tags workloads
-// @Success 204
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
 // @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
 // @Failure 500 {string} string "something went wrong"
 // @Router /analyticsworkloads [delete]
 func deleteOrphanedAnalyticsWorkloads(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodDelete, "/analyticsworkloads", func(c *gin.Context) {
-		wls, err := service.DeleteOrphanedAnalyticsWorkloads(c.Param("id"), c.GetHeader(HeaderAuth)[7:])
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOrphanedAnalyticsWorkloads(ctx, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
 		if err != nil {
 			util.Logger.Error("could not delete OrphanedAnalyticsWorkloads", "error", err)
 			_ = c.Error(handleError(err))
 			return
 		}
-		c.JSON(http.StatusOK, wls)
+		c.JSON(jobStatus(job), job)
 	}
 }
 
@@ -158,13 +486,13 @@ func deleteOrphanedAnalyticsWorkloads(service *service.CleanupService) (string,
 // @Description	Gets all orphaned kube services
 // @Tags kube-services
 // @Produce json
-// @Success	200 {array} lib.KubeService
+// @Success	200 {array} lib.Orphan[lib.KubeService]
 // @Failure 403 {string} string "forbidden"
 // @Failure 500 {string} string "something went wrong"
 // @Router /pipelinekubeservices [get]
 func getOrphanedKubeServices(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodGet, "/pipelinekubeservices", func(c *gin.Context) {
-		wls, err := service.GetOrphanedKubeServices(lib.PIPELINE)
+		wls, err := service.GetOrphanedKubeServices(c.Request.Context(), lib.PIPELINE)
 		if err != nil {
 			util.Logger.Error("could not get OrphanedKubeServices", "error", err)
 			_ = c.Error(handleError(err))
@@ -178,56 +506,242 @@ func getOrphanedKubeServices(service *service.CleanupService) (string, string, g
 // @Summary Delete orphaned kube service
 // @Description Deletes an orphaned kube service by name
 // @Tags kube-services
+// @Produce json
 // @Param name path string true "Kube Service name"
+// @Param dryRun query bool false "Only report what would be deleted"
 // @Success 204
+// @Success 200 {object} lib.DeleteResult "dry run"
+// @Failure 400 {string} string "bad request"
 // @Failure 403 {string} string "forbidden"
 // @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "not orphaned"
 // @Failure 500 {string} string "something went wrong"
 // @Router /pipelinekubeservices/{name} [delete]
 func deleteOrphanedKubeService(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodDelete, "/pipelinekubeservices/:id", func(c *gin.Context) {
-		err := service.DeleteOrphanedKubeService(lib.PIPELINE, c.Param("id"))
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		result, err := service.DeleteOrphan(ctx, kindKubeServices, lib.ResourceKindService, c.Param("id"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
 		if err != nil {
 			util.Logger.Error("could not delete OrphanedKubeService", "error", err)
 			_ = c.Error(handleError(err))
 			return
 		}
-		c.Status(http.StatusNoContent)
+		writeDeleteResult(c, result)
 	}
 }
 
 // deleteOrphanedKubeServices godoc
 // @Summary Delete orphaned kube services
-// @Description Deletes all orphaned kube services
+// @Description Starts a job deleting all orphaned kube services that passed the orphan grace period
 // @Tags kube-services
-// @Success 204
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
 // @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
 // @Failure 500 {string} string "something went wrong"
 // @Router /pipelinekubeservices [delete]
 func deleteOrphanedKubeServices(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodDelete, "/pipelinekubeservices", func(c *gin.Context) {
-		services, err := service.DeleteOrphanedKubeServices(lib.PIPELINE)
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOrphanedKubeServices(ctx, lib.PIPELINE, c.GetString(UserIdKey))
 		if err != nil {
 			util.Logger.Error("could not delete OrphanedKubeServices", "error", err)
 			_ = c.Error(handleError(err))
 			return
 		}
+		c.JSON(jobStatus(job), job)
+	}
+}
+
+// getOrphanedServingWorkloads godoc
+// @Summary Get all orphaned serving workloads
+// @Description	Gets all serving workloads whose serving instance no longer exists
+// @Tags serving-workloads
+// @Produce json
+// @Success	200 {array} lib.Orphan[lib.Workload]
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /servingworkloads [get]
+func getOrphanedServingWorkloads(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/servingworkloads", func(c *gin.Context) {
+		wls, err := service.GetOrphanedServingWorkloads(c.Request.Context(), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not get OrphanedServingWorkloads", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, wls)
+	}
+}
+
+// deleteOrphanedServingWorkload godoc
+// @Summary Delete orphaned serving workload
+// @Description Deletes an orphaned serving workload by name
+// @Tags serving-workloads
+// @Produce json
+// @Param   name path string true "Workload name"
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 204
+// @Success 200 {object} lib.DeleteResult "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "not orphaned"
+// @Failure 500 {string} string "something went wrong"
+// @Router /servingworkloads/{name} [delete]
+func deleteOrphanedServingWorkload(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/servingworkloads/:name", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		result, err := service.DeleteOrphan(ctx, kindServingWorkloads, lib.ResourceKindWorkload, c.Param("name"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete OrphanedServingWorkload", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		writeDeleteResult(c, result)
+	}
+}
+
+// deleteOrphanedServingWorkloads godoc
+// @Summary Delete orphaned serving workloads
+// @Description Starts a job deleting all orphaned serving workloads that passed the orphan grace period
+// @Tags serving-workloads
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
+// @Failure 500 {string} string "something went wrong"
+// @Router /servingworkloads [delete]
+func deleteOrphanedServingWorkloads(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/servingworkloads", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOrphanedServingWorkloads(ctx, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete OrphanedServingWorkloads", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(jobStatus(job), job)
+	}
+}
+
+// getOrphanedServingKubeServices godoc
+// @Summary Get all orphaned serving kube services
+// @Description	Gets all serving kube services without a workload of an existing serving instance
+// @Tags serving-kube-services
+// @Produce json
+// @Success	200 {array} lib.Orphan[lib.KubeService]
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /servingkubeservices [get]
+func getOrphanedServingKubeServices(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/servingkubeservices", func(c *gin.Context) {
+		services, err := service.GetOrphanedServingKubeServices(c.Request.Context(), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not get OrphanedServingKubeServices", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
 		c.JSON(http.StatusOK, services)
 	}
 }
 
+// deleteOrphanedServingKubeService godoc
+// @Summary Delete orphaned serving kube service
+// @Description Deletes an orphaned serving kube service by ID
+// @Tags serving-kube-services
+// @Produce json
+// @Param id path string true "Kube Service ID"
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 204
+// @Success 200 {object} lib.DeleteResult "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "not orphaned"
+// @Failure 500 {string} string "something went wrong"
+// @Router /servingkubeservices/{id} [delete]
+func deleteOrphanedServingKubeService(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/servingkubeservices/:id", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		result, err := service.DeleteOrphan(ctx, kindServingKubeServices, lib.ResourceKindService, c.Param("id"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete OrphanedServingKubeService", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		writeDeleteResult(c, result)
+	}
+}
+
+// deleteOrphanedServingKubeServices godoc
+// @Summary Delete orphaned serving kube services
+// @Description Starts a job deleting all orphaned serving kube services that passed the orphan grace period
+// @Tags serving-kube-services
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
+// @Failure 500 {string} string "something went wrong"
+// @Router /servingkubeservices [delete]
+func deleteOrphanedServingKubeServices(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/servingkubeservices", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOrphanedServingKubeServices(ctx, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete OrphanedServingKubeServices", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(jobStatus(job), job)
+	}
+}
+
 // getOrphanedKafkaTopics godoc
 // @Summary Get all orphaned kafka topics
 // @Description	Gets all orphaned kafka topics
 // @Tags kafka-topics
 // @Produce json
-// @Success	200 {array} string
+// @Success	200 {array} lib.Orphan[string]
 // @Failure 403 {string} string "forbidden"
 // @Failure 500 {string} string "something went wrong"
 // @Router /kafkatopics [get]
 func getOrphanedKafkaTopics(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodGet, "/kafkatopics", func(c *gin.Context) {
-		topics, err := service.GetOrphanedKafkaTopics()
+		topics, err := service.GetOrphanedKafkaTopics(c.Request.Context())
 		if err != nil {
 			util.Logger.Error("could not get OrphanedKafkaTopics", "error", err)
 			_ = c.Error(handleError(err))
@@ -241,42 +755,61 @@ func getOrphanedKafkaTopics(service *service.CleanupService) (string, string, gi
 // @Summary Delete orphaned kafka topic
 // @Description Deletes an orphaned kafka topic by name
 // @Tags kafka-topics
+// @Produce json
 // @Param name path string true "Kafka Topic name"
+// @Param dryRun query bool false "Only report what would be deleted"
 // @Success 204
+// @Success 200 {object} lib.DeleteResult "dry run"
+// @Failure 400 {string} string "bad request"
 // @Failure 403 {string} string "forbidden"
 // @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "not orphaned"
 // @Failure 500 {string} string "something went wrong"
 // @Router /kafkatopics/{name} [delete]
 func deleteOrphanedKafkaTopic(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodDelete, "/kafkatopics/:name", func(c *gin.Context) {
-		err := service.DeleteOrphanedKafkaTopic(c.Param("name"))
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		result, err := service.DeleteOrphan(ctx, kindKafkaTopics, lib.ResourceKindTopic, c.Param("name"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
 		if err != nil {
 			util.Logger.Error("could not delete OrphanedKafkaTopic", "error", err)
 			_ = c.Error(handleError(err))
 			return
 		}
-		c.Status(http.StatusNoContent)
+		writeDeleteResult(c, result)
 	}
 }
 
 // deleteOrphanedKafkaTopics godoc
 // @Summary Delete orphaned kafka topics
-// @Description Deletes all orphaned kafka topics
+// @Description Starts a job deleting all orphaned kafka topics that passed the orphan grace period
 // @Tags kafka-topics
-// @Success 204
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
 // @Failure 403 {string} string "forbidden"
 // @Failure 409 {string} string "already running"
 // @Failure 500 {string} string "something went wrong"
 // @Router /kafkatopics [delete]
 func deleteOrphanedKafkaTopics(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodDelete, "/kafkatopics", func(c *gin.Context) {
-		err := service.DeleteOrphanedKafkaTopics()
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOrphanedKafkaTopics(ctx, c.GetString(UserIdKey))
 		if err != nil {
 			util.Logger.Error("could not delete OrphanedKafkaTopics", "error", err)
 			_ = c.Error(handleError(err))
 			return
 		}
-		c.Status(http.StatusNoContent)
+		c.JSON(jobStatus(job), job)
 	}
 }
 
@@ -284,14 +817,20 @@ func deleteOrphanedKafkaTopics(service *service.CleanupService) (string, string,
 // @Summary Get kafka topic deletion status
 // @Description Get the status of kafka topic deletion
 // @Tags kafka-topics
-// @Success 200 {object} lib.DeleteStatus
+// @Produce json
+// @Success 200 {object} lib.Job
 // @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
 // @Failure 500 {string} string "something went wrong"
 // @Router /kafkatopics/status [get]
 func getDeleteOrphanedKafkaTopicsStatus(service *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodGet, "/kafkatopics/status", func(c *gin.Context) {
-		data := service.GetDeleteOrphanedKafkaTopicsStatus()
-		c.JSON(http.StatusOK, data)
+		job, err := service.GetDeleteOrphanedKafkaTopicsStatus()
+		if err != nil {
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, job)
 	}
 }
 
@@ -315,6 +854,539 @@ func stopDeleteOrphanedKafkaTopics(service *service.CleanupService) (string, str
 	}
 }
 
+// getOrphanedKafkaOutputTopics godoc
+// @Summary Get all orphaned kafka output topics
+// @Description	Gets all operator output topics that no pipeline produces or consumes
+// @Tags kafka-output-topics
+// @Produce json
+// @Success	200 {array} lib.Orphan[string]
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /kafkaoutputtopics [get]
+func getOrphanedKafkaOutputTopics(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/kafkaoutputtopics", func(c *gin.Context) {
+		topics, err := service.GetOrphanedKafkaOutputTopics(c.Request.Context(), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not get OrphanedKafkaOutputTopics", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, topics)
+	}
+}
+
+// deleteOrphanedKafkaOutputTopic godoc
+// @Summary Delete orphaned kafka output topic
+// @Description Deletes an orphaned kafka output topic by name
+// @Tags kafka-output-topics
+// @Produce json
+// @Param name path string true "Kafka Topic name"
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 204
+// @Success 200 {object} lib.DeleteResult "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "not orphaned"
+// @Failure 500 {string} string "something went wrong"
+// @Router /kafkaoutputtopics/{name} [delete]
+func deleteOrphanedKafkaOutputTopic(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/kafkaoutputtopics/:name", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		result, err := service.DeleteOrphan(ctx, kindKafkaOutputTopics, lib.ResourceKindTopic, c.Param("name"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete OrphanedKafkaOutputTopic", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		writeDeleteResult(c, result)
+	}
+}
+
+// deleteOrphanedKafkaOutputTopics godoc
+// @Summary Delete orphaned kafka output topics
+// @Description Starts a job deleting all orphaned kafka output topics that passed the orphan grace period
+// @Tags kafka-output-topics
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
+// @Failure 500 {string} string "something went wrong"
+// @Router /kafkaoutputtopics [delete]
+func deleteOrphanedKafkaOutputTopics(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/kafkaoutputtopics", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOrphanedKafkaOutputTopics(ctx, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete OrphanedKafkaOutputTopics", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(jobStatus(job), job)
+	}
+}
+
+// getDeleteOrphanedKafkaOutputTopicsStatus godoc
+// @Summary Get kafka output topic deletion status
+// @Description Get the status of kafka output topic deletion
+// @Tags kafka-output-topics
+// @Produce json
+// @Success 200 {object} lib.Job
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "something went wrong"
+// @Router /kafkaoutputtopics/status [get]
+func getDeleteOrphanedKafkaOutputTopicsStatus(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/kafkaoutputtopics/status", func(c *gin.Context) {
+		job, err := service.GetDeleteOrphanedKafkaOutputTopicsStatus()
+		if err != nil {
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// stopDeleteOrphanedKafkaOutputTopics godoc
+// @Summary Stop kafka output topic deletion
+// @Description Stops the deletion process of kafka output topics
+// @Tags kafka-output-topics
+// @Success 200
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /kafkaoutputtopics/stop [post]
+func stopDeleteOrphanedKafkaOutputTopics(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/kafkaoutputtopics/stop", func(c *gin.Context) {
+		err := service.StopDeleteOrphanedKafkaOutputTopics()
+		if err != nil {
+			util.Logger.Error("could not stop the deletion of OrphanedKafkaOutputTopics", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// getOrphanedKafkaConsumerGroups godoc
+// @Summary Get all orphaned kafka consumer groups
+// @Description	Gets all analytics consumer groups without a running pipeline and without active members
+// @Tags kafka-consumer-groups
+// @Produce json
+// @Success	200 {array} lib.Orphan[lib.ConsumerGroup]
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /kafkaconsumergroups [get]
+func getOrphanedKafkaConsumerGroups(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/kafkaconsumergroups", func(c *gin.Context) {
+		groups, err := service.GetOrphanedKafkaConsumerGroups(c.Request.Context())
+		if err != nil {
+			util.Logger.Error("could not get OrphanedKafkaConsumerGroups", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, groups)
+	}
+}
+
+// deleteOrphanedKafkaConsumerGroup godoc
+// @Summary Delete orphaned kafka consumer group
+// @Description Deletes an orphaned kafka consumer group by ID
+// @Tags kafka-consumer-groups
+// @Produce json
+// @Param id path string true "Consumer group ID"
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 204
+// @Success 200 {object} lib.DeleteResult "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "not orphaned or group still in use"
+// @Failure 500 {string} string "something went wrong"
+// @Router /kafkaconsumergroups/{id} [delete]
+func deleteOrphanedKafkaConsumerGroup(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/kafkaconsumergroups/:id", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		result, err := service.DeleteOrphan(ctx, kindKafkaConsumerGroups, lib.ResourceKindConsumerGroup, c.Param("id"), c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not delete OrphanedKafkaConsumerGroup", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		writeDeleteResult(c, result)
+	}
+}
+
+// deleteOrphanedKafkaConsumerGroups godoc
+// @Summary Delete orphaned kafka consumer groups
+// @Description Starts a job deleting all orphaned kafka consumer groups that passed the orphan grace period
+// @Tags kafka-consumer-groups
+// @Produce json
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 409 {string} string "already running"
+// @Failure 500 {string} string "something went wrong"
+// @Router /kafkaconsumergroups [delete]
+func deleteOrphanedKafkaConsumerGroups(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodDelete, "/kafkaconsumergroups", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.DeleteOrphanedKafkaConsumerGroups(ctx, c.GetString(UserIdKey))
+		if err != nil {
+			util.Logger.Error("could not delete OrphanedKafkaConsumerGroups", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(jobStatus(job), job)
+	}
+}
+
+// getSchedulerStatus godoc
+// @Summary Get scheduler status
+// @Description Get the schedule, the last and next run and the results of the last scheduled cleanup run
+// @Tags scheduler
+// @Produce json
+// @Success 200 {object} lib.SchedulerStatus
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /scheduler [get]
+func getSchedulerStatus(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/scheduler", func(c *gin.Context) {
+		c.JSON(http.StatusOK, service.GetSchedulerStatus())
+	}
+}
+
+// getJobs godoc
+// @Summary Get jobs
+// @Description Get the running and the last finished background jobs without their results, newest first
+// @Tags jobs
+// @Produce json
+// @Param type query string false "Only jobs of this type"
+// @Success 200 {array} lib.Job
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /jobs [get]
+func getJobs(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/jobs", func(c *gin.Context) {
+		c.JSON(http.StatusOK, service.GetJobs(c.Query("type")))
+	}
+}
+
+// getJob godoc
+// @Summary Get job
+// @Description Get the status, progress and per item results of a background job
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} lib.Job
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "something went wrong"
+// @Router /jobs/{id} [get]
+func getJob(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/jobs/:id", func(c *gin.Context) {
+		job, err := service.GetJob(c.Param("id"))
+		if err != nil {
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// stopJob godoc
+// @Summary Stop job
+// @Description Stops a running background job after the current item
+// @Tags jobs
+// @Param id path string true "Job ID"
+// @Success 200
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "job not running"
+// @Failure 500 {string} string "something went wrong"
+// @Router /jobs/{id}/stop [post]
+func stopJob(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/jobs/:id/stop", func(c *gin.Context) {
+		err := service.StopJob(c.Param("id"))
+		if err != nil {
+			util.Logger.Error("could not stop job", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// resumeJob godoc
+// @Summary Resume job
+// @Description Resumes an interrupted or canceled background job with the service account. Pending items are verified again, items that no longer qualify are skipped.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 202 {object} lib.Job
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "job not resumable"
+// @Failure 500 {string} string "something went wrong"
+// @Router /jobs/{id}/resume [post]
+func resumeJob(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/jobs/:id/resume", func(c *gin.Context) {
+		job, err := service.ResumeJob(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			util.Logger.Error("could not resume job", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+// createPlan godoc
+// @Summary Create plan
+// @Description Snapshots the deletable orphans of the requested kinds into a persisted plan. Without kinds, all kinds that can be deleted are planned. Large plans stay pending until enough other admins approved them.
+// @Tags plans
+// @Accept json
+// @Produce json
+// @Param request body lib.PlanRequest false "Kinds to plan"
+// @Success 201 {object} lib.Plan
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /plans [post]
+func createPlan(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/plans", func(c *gin.Context) {
+		var request lib.PlanRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				_ = c.Error(lib.NewInputError(err))
+				return
+			}
+		}
+		plan, err := service.CreatePlan(c.Request.Context(), request, c.GetString(UserIdKey), c.GetHeader(HeaderAuth)[7:])
+		if err != nil {
+			util.Logger.Error("could not create plan", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusCreated, plan)
+	}
+}
+
+// getPlans godoc
+// @Summary Get plans
+// @Description Lists all plans without their items, newest first
+// @Tags plans
+// @Produce json
+// @Success 200 {array} lib.Plan
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /plans [get]
+func getPlans(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/plans", func(c *gin.Context) {
+		plans, err := service.GetPlans()
+		if err != nil {
+			util.Logger.Error("could not get plans", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, plans)
+	}
+}
+
+// getPlan godoc
+// @Summary Get plan
+// @Description Get a plan with its items
+// @Tags plans
+// @Produce json
+// @Param id path string true "Plan ID"
+// @Success 200 {object} lib.Plan
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "something went wrong"
+// @Router /plans/{id} [get]
+func getPlan(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/plans/:id", func(c *gin.Context) {
+		plan, err := service.GetPlan(c.Param("id"))
+		if err != nil {
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+	}
+}
+
+// approvePlan godoc
+// @Summary Approve plan
+// @Description Approves a pending plan. Approvals must come from distinct admins other than the creator, the plan is approved once it has enough of them.
+// @Tags plans
+// @Produce json
+// @Param id path string true "Plan ID"
+// @Param hash query string false "Hash of the reviewed plan, the approval fails if it does not match"
+// @Success 200 {object} lib.Plan
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "plan not pending or already approved by the caller"
+// @Failure 500 {string} string "something went wrong"
+// @Router /plans/{id}/approve [post]
+func approvePlan(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/plans/:id/approve", func(c *gin.Context) {
+		plan, err := service.ApprovePlan(c.Param("id"), c.Query("hash"), c.GetString(UserIdKey))
+		if err != nil {
+			util.Logger.Error("could not approve plan", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+	}
+}
+
+// rejectPlan godoc
+// @Summary Reject plan
+// @Description Rejects a pending or approved plan, it can not be applied afterward
+// @Tags plans
+// @Produce json
+// @Param id path string true "Plan ID"
+// @Param reason query string false "Reason for the rejection"
+// @Success 200 {object} lib.Plan
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "plan not pending"
+// @Failure 500 {string} string "something went wrong"
+// @Router /plans/{id}/reject [post]
+func rejectPlan(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/plans/:id/reject", func(c *gin.Context) {
+		plan, err := service.RejectPlan(c.Param("id"), c.Query("reason"), c.GetString(UserIdKey))
+		if err != nil {
+			util.Logger.Error("could not reject plan", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+	}
+}
+
+// applyPlan godoc
+// @Summary Apply plan
+// @Description Deletes the items of an approved plan in a background job. Each item is verified to be still a deletable orphan right before it is deleted, items that are not are skipped. A plan can only be applied once.
+// @Tags plans
+// @Produce json
+// @Param id path string true "Plan ID"
+// @Param hash query string false "Hash of the reviewed plan, the apply fails if it does not match"
+// @Param dryRun query bool false "Only report what would be deleted"
+// @Success 202 {object} lib.Job
+// @Success 200 {object} lib.Job "dry run"
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "plan not pending or not approved"
+// @Failure 500 {string} string "something went wrong"
+// @Router /plans/{id}/apply [post]
+func applyPlan(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/plans/:id/apply", func(c *gin.Context) {
+		ctx, err := deleteContext(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		job, err := service.ApplyPlan(ctx, c.Param("id"), c.Query("hash"), c.GetString(UserIdKey))
+		if err != nil {
+			util.Logger.Error("could not apply plan", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(jobStatus(job), job)
+	}
+}
+
+// getQuarantine godoc
+// @Summary Get quarantine
+// @Description Get the resources deleted in quarantine mode, newest first. Entries are purged after the retention period.
+// @Tags quarantine
+// @Produce json
+// @Success 200 {array} lib.QuarantineEntry
+// @Failure 403 {string} string "forbidden"
+// @Failure 500 {string} string "something went wrong"
+// @Router /quarantine [get]
+func getQuarantine(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/quarantine", func(c *gin.Context) {
+		entries, err := service.GetQuarantine()
+		if err != nil {
+			util.Logger.Error("could not get quarantine", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+// getQuarantineEntry godoc
+// @Summary Get quarantine entry
+// @Description Get a quarantine entry with the spec its resource is restored from
+// @Tags quarantine
+// @Produce json
+// @Param id path string true "Quarantine entry ID"
+// @Success 200 {object} lib.QuarantineEntry
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "something went wrong"
+// @Router /quarantine/{id} [get]
+func getQuarantineEntry(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodGet, "/quarantine/:id", func(c *gin.Context) {
+		entry, err := service.GetQuarantineEntry(c.Param("id"))
+		if err != nil {
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+// restoreQuarantined godoc
+// @Summary Restore quarantined resource
+// @Description Recreates the resource of a quarantine entry. Pipelines are recreated in the name of their owner. Restored entries are kept until they are purged.
+// @Tags quarantine
+// @Produce json
+// @Param id path string true "Quarantine entry ID"
+// @Success 200 {object} lib.QuarantineEntry
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found"
+// @Failure 409 {string} string "already restored"
+// @Failure 500 {string} string "something went wrong"
+// @Router /quarantine/{id}/restore [post]
+func restoreQuarantined(service *service.CleanupService) (string, string, gin.HandlerFunc) {
+	return http.MethodPost, "/quarantine/:id/restore", func(c *gin.Context) {
+		entry, err := service.RestoreQuarantined(c.Request.Context(), c.Param("id"), c.GetString(UserIdKey))
+		if err != nil {
+			util.Logger.Error("could not restore quarantined resource", "error", err)
+			_ = c.Error(handleError(err))
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
 func getHealthCheckH(_ *service.CleanupService) (string, string, gin.HandlerFunc) {
 	return http.MethodGet, HealthCheckPath, func(c *gin.Context) {
 		c.Status(http.StatusOK)
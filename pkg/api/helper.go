@@ -17,23 +17,73 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"strconv"
 
 	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/service"
+	"github.com/gin-gonic/gin"
 )
 
 func handleError(err error) error {
 	var ie *lib.ForbiddenError
 	var ce *lib.ConflictError
 	var ne *lib.NotFoundError
+	var pe *lib.InputError
 	if errors.As(err, &ie) {
 		err = lib.NewForbiddenError(errors.New(MessageForbidden))
 	} else if errors.As(err, &ce) {
-		err = lib.NewConflictError(errors.New(MessageConflict))
+		// conflicts explain which state prevents the request, the caller needs that to resolve it
+		err = lib.NewConflictError(errors.New(ce.Error()))
 	} else if errors.As(err, &ne) {
 		err = lib.NewNotFoundError(errors.New(MessageNotFound))
+	} else if errors.As(err, &pe) {
+		err = lib.NewInputError(errors.New(MessageBadRequest))
 	} else {
 		err = lib.NewInternalError(errors.New(MessageSomethingWrong))
 	}
 	return err
 }
+
+// deleteContext returns the context of the request, marked as dry run if the dryRun query parameter is set.
+func deleteContext(c *gin.Context) (context.Context, error) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		return nil, lib.NewInputError(err)
+	}
+	if dryRun {
+		return service.WithDryRun(c.Request.Context()), nil
+	}
+	return c.Request.Context(), nil
+}
+
+// jobStatus returns 202 for started jobs and 200 for dry runs, they are finished already.
+func jobStatus(job lib.Job) int {
+	if job.DryRun {
+		return http.StatusOK
+	}
+	return http.StatusAccepted
+}
+
+// orphan kinds the single deletes are verified against, the handlers shadow the service package
+const (
+	kindPipelines           = service.OrphanKindPipelines
+	kindAnalyticsWorkloads  = service.OrphanKindAnalyticsWorkloads
+	kindServingWorkloads    = service.OrphanKindServingWorkloads
+	kindKubeServices        = service.OrphanKindKubeServices
+	kindServingKubeServices = service.OrphanKindServingKubeServices
+	kindKafkaTopics         = service.OrphanKindKafkaTopics
+	kindKafkaOutputTopics   = service.OrphanKindKafkaOutputTopics
+	kindKafkaConsumerGroups = service.OrphanKindKafkaConsumerGroups
+)
+
+// writeDeleteResult answers a single delete, with 204 or, for dry runs, with what would have been deleted and why.
+func writeDeleteResult(c *gin.Context, result lib.DeleteResult) {
+	if result.Status == lib.DeleteResultWouldDelete {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
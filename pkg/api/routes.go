@@ -30,15 +30,63 @@ var routesAuth = gin_mw.Routes[*service.CleanupService]{
 	getOrphanedPipelineServices,
 	deleteOrphanedPipelineService,
 	deleteOrphanedPipelineServices,
+	getOwnerlessPipelines,
+	deleteOwnerlessPipelines,
+	getPipelinesWithDanglingFlows,
+	getPipelineResources,
+	deletePipeline,
+	deleteUserResources,
+	getOffboardingReports,
+	recreatePipelines,
+	getRecreatePipelinesStatus,
+	stopRecreatePipelines,
 	getOrphanedAnalyticsWorkloads,
+	getAnalyticsWorkloadOwnership,
 	deleteOrphanedAnalyticsWorkload,
 	deleteOrphanedAnalyticsWorkloads,
 	getOrphanedKubeServices,
 	deleteOrphanedKubeService,
 	deleteOrphanedKubeServices,
+	getOrphanedServingWorkloads,
+	deleteOrphanedServingWorkload,
+	deleteOrphanedServingWorkloads,
+	getOrphanedServingKubeServices,
+	deleteOrphanedServingKubeService,
+	deleteOrphanedServingKubeServices,
 	getOrphanedKafkaTopics,
 	deleteOrphanedKafkaTopic,
 	deleteOrphanedKafkaTopics,
 	stopDeleteOrphanedKafkaTopics,
 	getDeleteOrphanedKafkaTopicsStatus,
+	getOrphanedKafkaOutputTopics,
+	deleteOrphanedKafkaOutputTopic,
+	deleteOrphanedKafkaOutputTopics,
+	stopDeleteOrphanedKafkaOutputTopics,
+	getDeleteOrphanedKafkaOutputTopicsStatus,
+	getOrphanedKafkaConsumerGroups,
+	deleteOrphanedKafkaConsumerGroup,
+	deleteOrphanedKafkaConsumerGroups,
+	getSchedulerStatus,
+	getJobs,
+	getJob,
+	stopJob,
+	resumeJob,
+	createPlan,
+	getPlans,
+	getPlan,
+	approvePlan,
+	rejectPlan,
+	applyPlan,
+	getQuarantine,
+	getQuarantineEntry,
+	restoreQuarantined,
+}
+
+// the cron mode serves the scheduler status only
+var routesStatus = gin_mw.Routes[*service.CleanupService]{
+	getHealthCheckH,
+}
+
+var routesStatusAuth = gin_mw.Routes[*service.CleanupService]{
+	getSchedulerStatus,
 }
@@ -0,0 +1,287 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docker_api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+// ProjectLabel groups containers and networks like a namespace, as set by docker compose.
+const ProjectLabel = "com.docker.compose.project"
+
+type Docker struct {
+	url            string
+	client         *http.Client
+	servingProject string
+	pipeProject    string
+}
+
+// NewDocker creates a driver for the docker engine at host, e.g. unix:///var/run/docker.sock or tcp://localhost:2375.
+func NewDocker(host string, servingProject string, pipeProject string, timeout time.Duration) (*Docker, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: timeout}
+	baseUrl := ""
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}
+		baseUrl = "http://docker"
+	case "tcp", "http":
+		baseUrl = "http://" + u.Host
+	case "https":
+		baseUrl = "https://" + u.Host
+	default:
+		return nil, errors.New("unsupported docker host: " + host)
+	}
+	return &Docker{baseUrl, client, servingProject, pipeProject}, nil
+}
+
+func (d *Docker) GetServices(ctx context.Context, collection string) (services []lib.KubeService, err error) {
+	project := d.getProject(collection)
+	containers, err := d.listContainers(ctx, project)
+	if err != nil {
+		return
+	}
+	var networks []Network
+	err = d.get(ctx, "/networks?filters="+getProjectFilter(project), &networks)
+	if err != nil {
+		err = errors.New("could not get networks: " + err.Error())
+		return
+	}
+	for _, network := range networks {
+		service := lib.KubeService{
+			Id:       network.Id,
+			Name:     network.Name,
+			BaseType: "network",
+		}
+		for _, container := range containers {
+			for _, endpoint := range container.NetworkSettings.Networks {
+				if endpoint.NetworkID == network.Id {
+					service.TargetWorkloadIds = append(service.TargetWorkloadIds, getWorkloadId(project, container))
+					break
+				}
+			}
+		}
+		services = append(services, service)
+	}
+	return
+}
+
+func (d *Docker) GetWorkloads(ctx context.Context, collection string) (workloads []lib.Workload, err error) {
+	containers, err := d.listContainers(ctx, d.getProject(collection))
+	if err != nil {
+		return
+	}
+	for _, container := range containers {
+		var env map[string]string
+		env, err = d.getEnv(ctx, container.Id)
+		if err != nil {
+			return
+		}
+		workloads = append(workloads, lib.Workload{
+			Id:          container.Id,
+			Name:        getContainerName(container),
+			Type:        lib.WorkloadTypeContainer,
+			ImageUuid:   container.Image,
+			Environment: env,
+			Labels:      container.Labels,
+		})
+	}
+	return
+}
+
+func (d *Docker) GetWorkloadEnvs(ctx context.Context, collection string) (envs []map[string]string, err error) {
+	containers, err := d.listContainers(ctx, d.getProject(collection))
+	if err != nil {
+		return
+	}
+	for _, container := range containers {
+		var env map[string]string
+		env, err = d.getEnv(ctx, container.Id)
+		if err != nil {
+			return
+		}
+		envs = append(envs, env)
+	}
+	return
+}
+
+func (d *Docker) DeleteWorkload(ctx context.Context, workloadId string, _ string) (err error) {
+	_, _, workloadId, _ = lib.ParseWorkloadId(workloadId)
+	err = d.delete(ctx, "/containers/"+url.PathEscape(workloadId)+"?force=true")
+	if err != nil && !isNotFound(err) {
+		err = errors.New("could not delete operator: " + err.Error())
+	}
+	return
+}
+
+func (d *Docker) DeleteService(ctx context.Context, serviceId string, _ string) (err error) {
+	err = d.delete(ctx, "/networks/"+url.PathEscape(serviceId))
+	if err != nil && !isNotFound(err) {
+		err = errors.New("could not delete service: " + err.Error())
+	}
+	return
+}
+
+func (d *Docker) listContainers(ctx context.Context, project string) (containers []Container, err error) {
+	err = d.get(ctx, "/containers/json?all=true&filters="+getProjectFilter(project), &containers)
+	if err != nil {
+		err = errors.New("could not get workloads: " + err.Error())
+	}
+	return
+}
+
+func (d *Docker) getEnv(ctx context.Context, containerId string) (env map[string]string, err error) {
+	var inspect ContainerInspect
+	err = d.get(ctx, "/containers/"+url.PathEscape(containerId)+"/json", &inspect)
+	if err != nil {
+		err = errors.New("could not inspect container " + containerId + ": " + err.Error())
+		return
+	}
+	env = map[string]string{}
+	for _, v := range inspect.Config.Env {
+		key, value, _ := strings.Cut(v, "=")
+		env[key] = value
+	}
+	return
+}
+
+func (d *Docker) get(ctx context.Context, path string, result interface{}) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url+path, nil)
+	if err != nil {
+		return
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return getError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (d *Docker) delete(ctx context.Context, path string) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, d.url+path, nil)
+	if err != nil {
+		return
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return getError(resp)
+	}
+	return
+}
+
+func (d *Docker) post(ctx context.Context, path string, payload interface{}, result interface{}) (err error) {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url+path, body)
+	if err != nil {
+		return
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return getError(resp)
+	}
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return
+}
+
+func (d *Docker) getProject(collection string) string {
+	if collection == lib.SERVING {
+		return d.servingProject
+	}
+	return d.pipeProject
+}
+
+func getError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	var errResp ErrorResponse
+	message := string(body)
+	if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
+		message = errResp.Message
+	}
+	err := errors.New(strconv.Itoa(resp.StatusCode) + " " + message)
+	if resp.StatusCode == http.StatusNotFound {
+		return lib.NewNotFoundError(err)
+	}
+	if resp.StatusCode == http.StatusConflict {
+		return lib.NewConflictError(err)
+	}
+	return err
+}
+
+func isNotFound(err error) bool {
+	var nfe *lib.NotFoundError
+	return errors.As(err, &nfe)
+}
+
+func getProjectFilter(project string) string {
+	filter, _ := json.Marshal(map[string][]string{"label": {ProjectLabel + "=" + project}})
+	return url.QueryEscape(string(filter))
+}
+
+func getContainerName(container Container) string {
+	if len(container.Names) == 0 {
+		return container.Id
+	}
+	return strings.TrimPrefix(container.Names[0], "/")
+}
+
+func getWorkloadId(project string, container Container) string {
+	return lib.NewWorkloadId(lib.WorkloadTypeContainer, project, getContainerName(container))
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docker_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+// newTestEngine stands in for the docker engine api with two containers and two networks of project pipes.
+func newTestEngine(t *testing.T, deleted *[]string) *Docker {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Query().Get("filters"), ProjectLabel+"=pipes") {
+			_ = json.NewEncoder(w).Encode([]Container{})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]Container{
+			{Id: "c1", Names: []string{"/op-1"}, Image: "image-1", Labels: map[string]string{ProjectLabel: "pipes"},
+				NetworkSettings: NetworkSettings{Networks: map[string]EndpointSettings{"pipes_default": {NetworkID: "n1"}}}},
+			{Id: "c2", Names: []string{"/op-2"}, Image: "image-2", Labels: map[string]string{ProjectLabel: "pipes"}},
+		})
+	})
+	mux.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		inspect := ContainerInspect{Id: r.PathValue("id"), Config: ContainerConfig{Env: []string{"PIPELINE_ID=" + r.PathValue("id"), "EMPTY="}}}
+		_ = json.NewEncoder(w).Encode(inspect)
+	})
+	mux.HandleFunc("GET /networks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]Network{{Id: "n1", Name: "pipes_default"}, {Id: "n2", Name: "pipes_unused"}})
+	})
+	mux.HandleFunc("DELETE /containers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "op-1" {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{Message: "No such container: " + r.PathValue("id")})
+			return
+		}
+		if r.URL.Query().Get("force") != "true" {
+			t.Error("containers should be removed with force")
+		}
+		*deleted = append(*deleted, r.PathValue("id"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /networks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Message: "network has active endpoints"})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	d, err := NewDocker(server.URL, "serving", "pipes", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestGetWorkloads(t *testing.T) {
+	d := newTestEngine(t, nil)
+	workloads, err := d.GetWorkloads(context.Background(), lib.PIPELINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workloads) != 2 {
+		t.Fatalf("expected two workloads, got %+v", workloads)
+	}
+	workload := workloads[0]
+	if workload.Id != "c1" || workload.Name != "op-1" || workload.Type != lib.WorkloadTypeContainer || workload.ImageUuid != "image-1" {
+		t.Fatalf("unexpected workload %+v", workload)
+	}
+	if workload.Environment["PIPELINE_ID"] != "c1" || workload.Environment["EMPTY"] != "" {
+		t.Fatalf("unexpected environment %+v", workload.Environment)
+	}
+	serving, err := d.GetWorkloads(context.Background(), lib.SERVING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serving) != 0 {
+		t.Fatalf("expected no serving workloads, got %+v", serving)
+	}
+}
+
+func TestGetServices(t *testing.T) {
+	d := newTestEngine(t, nil)
+	services, err := d.GetServices(context.Background(), lib.PIPELINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("expected two services, got %+v", services)
+	}
+	if services[0].Id != "n1" || services[0].BaseType != "network" || !slices.Equal(services[0].TargetWorkloadIds, []string{"container:pipes:op-1"}) {
+		t.Fatalf("unexpected service %+v", services[0])
+	}
+	if services[1].Id != "n2" || len(services[1].TargetWorkloadIds) != 0 {
+		t.Fatalf("unexpected service %+v", services[1])
+	}
+}
+
+func TestDelete(t *testing.T) {
+	var deleted []string
+	d := newTestEngine(t, &deleted)
+	ctx := context.Background()
+	if err := d.DeleteWorkload(ctx, "container:pipes:op-1", lib.PIPELINE); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deleted, []string{"op-1"}) {
+		t.Fatalf("unexpected deletes %v", deleted)
+	}
+	var notFound *lib.NotFoundError
+	if err := d.DeleteWorkload(ctx, "missing", lib.PIPELINE); !errors.As(err, &notFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	err := d.DeleteService(ctx, "n1", lib.PIPELINE)
+	if err == nil || !strings.Contains(err.Error(), "network has active endpoints") {
+		t.Fatalf("expected engine error, got %v", err)
+	}
+	if errors.As(err, &notFound) {
+		t.Fatalf("unexpected not found %v", err)
+	}
+}
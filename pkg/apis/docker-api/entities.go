@@ -0,0 +1,98 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docker_api
+
+import "encoding/json"
+
+type Container struct {
+	Id              string            `json:"Id"`
+	Names           []string          `json:"Names"`
+	Image           string            `json:"Image"`
+	Labels          map[string]string `json:"Labels"`
+	NetworkSettings NetworkSettings   `json:"NetworkSettings"`
+}
+
+type NetworkSettings struct {
+	Networks map[string]EndpointSettings `json:"Networks"`
+}
+
+type EndpointSettings struct {
+	NetworkID string `json:"NetworkID"`
+}
+
+type ContainerInspect struct {
+	Id     string          `json:"Id"`
+	Name   string          `json:"Name"`
+	Config ContainerConfig `json:"Config"`
+}
+
+type ContainerConfig struct {
+	Image  string            `json:"Image"`
+	Env    []string          `json:"Env"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type Network struct {
+	Id     string            `json:"Id"`
+	Name   string            `json:"Name"`
+	Driver string            `json:"Driver"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type ErrorResponse struct {
+	Message string `json:"message"`
+}
+
+// ContainerSpec holds everything needed to create a container again.
+type ContainerSpec struct {
+	Name       string                    `json:"name"`
+	Running    bool                      `json:"running"`
+	Config     json.RawMessage           `json:"config"`
+	HostConfig json.RawMessage           `json:"host_config"`
+	Networks   map[string]EndpointConfig `json:"networks,omitempty"`
+}
+
+type EndpointConfig struct {
+	Aliases    []string          `json:"Aliases,omitempty"`
+	Links      []string          `json:"Links,omitempty"`
+	IPAMConfig json.RawMessage   `json:"IPAMConfig,omitempty"`
+	DriverOpts map[string]string `json:"DriverOpts,omitempty"`
+}
+
+type containerExport struct {
+	Name       string          `json:"Name"`
+	Config     json.RawMessage `json:"Config"`
+	HostConfig json.RawMessage `json:"HostConfig"`
+	State      struct {
+		Running bool `json:"Running"`
+	} `json:"State"`
+	NetworkSettings struct {
+		Networks map[string]EndpointConfig `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// NetworkSpec holds the settings of /networks/create.
+type NetworkSpec struct {
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver,omitempty"`
+	Internal   bool              `json:"Internal,omitempty"`
+	Attachable bool              `json:"Attachable,omitempty"`
+	EnableIPv6 bool              `json:"EnableIPv6,omitempty"`
+	IPAM       json.RawMessage   `json:"IPAM,omitempty"`
+	Options    map[string]string `json:"Options,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package docker_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func (d *Docker) ExportWorkload(ctx context.Context, workloadId string, _ string) (spec json.RawMessage, err error) {
+	_, _, workloadId, _ = lib.ParseWorkloadId(workloadId)
+	var inspect containerExport
+	err = d.get(ctx, "/containers/"+url.PathEscape(workloadId)+"/json", &inspect)
+	if err != nil {
+		if !isNotFound(err) {
+			err = errors.New("could not export operator: " + err.Error())
+		}
+		return
+	}
+	return json.Marshal(ContainerSpec{
+		Name:       strings.TrimPrefix(inspect.Name, "/"),
+		Running:    inspect.State.Running,
+		Config:     inspect.Config,
+		HostConfig: inspect.HostConfig,
+		Networks:   inspect.NetworkSettings.Networks,
+	})
+}
+
+// RestoreWorkload creates the container again and starts it, if it was running when exported.
+func (d *Docker) RestoreWorkload(ctx context.Context, spec json.RawMessage, _ string) (err error) {
+	var container ContainerSpec
+	if err = json.Unmarshal(spec, &container); err != nil {
+		return
+	}
+	body := map[string]any{}
+	if err = json.Unmarshal(container.Config, &body); err != nil {
+		return
+	}
+	body["HostConfig"] = container.HostConfig
+	body["NetworkingConfig"] = map[string]any{"EndpointsConfig": container.Networks}
+	var created struct {
+		Id string `json:"Id"`
+	}
+	err = d.post(ctx, "/containers/create?name="+url.QueryEscape(container.Name), body, &created)
+	if err != nil {
+		return
+	}
+	if container.Running {
+		err = d.post(ctx, "/containers/"+url.PathEscape(created.Id)+"/start", nil, nil)
+		if err != nil {
+			err = errors.New("could not start operator: " + err.Error())
+		}
+	}
+	return
+}
+
+func (d *Docker) ExportService(ctx context.Context, serviceId string, _ string) (spec json.RawMessage, err error) {
+	var network NetworkSpec
+	err = d.get(ctx, "/networks/"+url.PathEscape(serviceId), &network)
+	if err != nil {
+		if !isNotFound(err) {
+			err = errors.New("could not export service: " + err.Error())
+		}
+		return
+	}
+	return json.Marshal(network)
+}
+
+func (d *Docker) RestoreService(ctx context.Context, spec json.RawMessage, _ string) (err error) {
+	var network NetworkSpec
+	if err = json.Unmarshal(spec, &network); err != nil {
+		return
+	}
+	return d.post(ctx, "/networks/create", network, nil)
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/pkg/errors"
+)
+
+type FlowRepoService struct {
+	url    string
+	client *http.Client
+}
+
+func NewFlowRepoService(url string, timeout time.Duration) *FlowRepoService {
+	return &FlowRepoService{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// GetFlow returns a lib.NotFoundError if the flow does not exist in the flow repository.
+func (f FlowRepoService) GetFlow(ctx context.Context, id string, userId string, accessToken string) (flow lib.Flow, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url+"/flow/"+url.PathEscape(id), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("X-UserId", userId)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	statusCode, body, err := doRequest(f.client, req)
+	if err != nil {
+		return
+	}
+	if statusCode == http.StatusNotFound {
+		return flow, lib.NewNotFoundError(errors.New("flow " + id + " not found"))
+	}
+	if statusCode != http.StatusOK {
+		return flow, errors.New("could not access flow repository: " + strconv.Itoa(statusCode) + " " + body)
+	}
+	err = json.Unmarshal([]byte(body), &flow)
+	return
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func doRequest(client *http.Client, req *http.Request) (statusCode int, body string, err error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b), err
+}
+
+// withContext runs f and returns as soon as ctx is done. For clients without context support,
+// f keeps running in the background until it hits its own timeout, but the caller is released.
+func withContext[T any](ctx context.Context, f func() (T, error)) (result T, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	type res struct {
+		value T
+		err   error
+	}
+	ch := make(chan res, 1)
+	go func() {
+		value, err := f()
+		ch <- res{value, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return result, ctx.Err()
+	case r := <-ch:
+		return r.value, r.err
+	}
+}
+
+// deleteWithContext runs the delete f like withContext, it is not issued at all if ctx is done already. If ctx is done
+// while f is in flight, f keeps running and usually succeeds, so a lib.IndeterminateError is returned instead of a
+// failure.
+func deleteWithContext(ctx context.Context, f func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := withContext(ctx, func() (struct{}, error) {
+		return struct{}{}, f()
+	})
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return lib.NewIndeterminateError(errors.New("canceled while the delete was in flight, it may still succeed: " + err.Error()))
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func TestDeleteWithContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := deleteWithContext(canceled, func() error {
+		called = true
+		return nil
+	})
+	if called || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the delete not to be issued, called %v, got %v", called, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	go func() {
+		<-started
+		cancel()
+	}()
+	err = deleteWithContext(ctx, func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	var indeterminate *lib.IndeterminateError
+	if !errors.As(err, &indeterminate) {
+		t.Errorf("expected an indeterminate outcome for a delete canceled in flight, got %v", err)
+	}
+
+	failure := errors.New("failure")
+	if err = deleteWithContext(context.Background(), func() error { return failure }); !errors.Is(err, failure) {
+		t.Errorf("expected the delete error, got %v", err)
+	}
+}
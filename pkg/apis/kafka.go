@@ -17,6 +17,7 @@
 package apis
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -51,16 +52,18 @@ func (k *KafkaAdmin) Close() (err error) {
 	return
 }
 
-func (k *KafkaAdmin) DeleteTopic(name string) (err error) {
-	err = k.clusterAdmin.DeleteTopic(name)
+func (k *KafkaAdmin) DeleteTopic(ctx context.Context, name string) (err error) {
+	err = deleteWithContext(ctx, func() error {
+		return k.clusterAdmin.DeleteTopic(name)
+	})
 	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
 		err = lib.NewNotFoundError(err)
 	}
 	return
 }
 
-func (k *KafkaAdmin) GetTopics() (topics []string, err error) {
-	topicInfos, err := k.clusterAdmin.ListTopics()
+func (k *KafkaAdmin) GetTopics(ctx context.Context) (topics []string, err error) {
+	topicInfos, err := withContext(ctx, k.clusterAdmin.ListTopics)
 	if err != nil {
 		return nil, err
 	}
@@ -69,3 +72,108 @@ func (k *KafkaAdmin) GetTopics() (topics []string, err error) {
 	}
 	return topics, nil
 }
+
+func (k *KafkaAdmin) ListConsumerGroups(ctx context.Context) (groups []string, err error) {
+	groupInfos, err := withContext(ctx, k.clusterAdmin.ListConsumerGroups)
+	if err != nil {
+		return nil, err
+	}
+	for group := range groupInfos {
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func (k *KafkaAdmin) DescribeConsumerGroups(ctx context.Context, groups []string) (descriptions []lib.ConsumerGroup, err error) {
+	if len(groups) == 0 {
+		return
+	}
+	groupDescriptions, err := withContext(ctx, func() ([]*sarama.GroupDescription, error) {
+		return k.clusterAdmin.DescribeConsumerGroups(groups)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, description := range groupDescriptions {
+		if !errors.Is(description.Err, sarama.ErrNoError) {
+			return nil, errors.New("could not describe consumer group " + description.GroupId + ": " + description.Err.Error())
+		}
+		descriptions = append(descriptions, lib.ConsumerGroup{
+			Id:      description.GroupId,
+			State:   description.State,
+			Members: len(description.Members),
+		})
+	}
+	return
+}
+
+func (k *KafkaAdmin) DeleteConsumerGroup(ctx context.Context, group string) (err error) {
+	err = deleteWithContext(ctx, func() error {
+		return k.clusterAdmin.DeleteConsumerGroup(group)
+	})
+	if errors.Is(err, sarama.ErrGroupIDNotFound) {
+		err = lib.NewNotFoundError(err)
+	}
+	if errors.Is(err, sarama.ErrNonEmptyGroup) {
+		err = lib.NewConflictError(err)
+	}
+	return
+}
+
+// DescribeTopic returns the partition layout and the topic specific configs of topic name.
+func (k *KafkaAdmin) DescribeTopic(ctx context.Context, name string) (spec lib.TopicSpec, err error) {
+	metadata, err := withContext(ctx, func() ([]*sarama.TopicMetadata, error) {
+		return k.clusterAdmin.DescribeTopics([]string{name})
+	})
+	if err != nil {
+		return
+	}
+	if len(metadata) != 1 {
+		return spec, errors.New("could not describe topic " + name)
+	}
+	if errors.Is(metadata[0].Err, sarama.ErrUnknownTopicOrPartition) {
+		return spec, lib.NewNotFoundError(errors.New("topic " + name + " not found"))
+	}
+	if !errors.Is(metadata[0].Err, sarama.ErrNoError) {
+		return spec, errors.New("could not describe topic " + name + ": " + metadata[0].Err.Error())
+	}
+	spec = lib.TopicSpec{Name: name, Partitions: map[int32][]int32{}, Config: map[string]string{}}
+	for _, partition := range metadata[0].Partitions {
+		spec.Partitions[partition.ID] = partition.Replicas
+		spec.ReplicationFactor = int16(len(partition.Replicas))
+	}
+	entries, err := withContext(ctx, func() ([]sarama.ConfigEntry, error) {
+		return k.clusterAdmin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: name})
+	})
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		// only configs set on the topic itself, the others follow the broker
+		if entry.Source == sarama.SourceTopic {
+			spec.Config[entry.Name] = entry.Value
+		}
+	}
+	return
+}
+
+// CreateTopic creates a topic with the partition count, replication factor and configs of spec. The brokers
+// assign the replicas, the recorded assignment may reference brokers that are gone.
+func (k *KafkaAdmin) CreateTopic(ctx context.Context, spec lib.TopicSpec) (err error) {
+	config := map[string]*string{}
+	for name, value := range spec.Config {
+		config[name] = &value
+	}
+	detail := &sarama.TopicDetail{
+		NumPartitions:     int32(len(spec.Partitions)),
+		ReplicationFactor: spec.ReplicationFactor,
+		ConfigEntries:     config,
+	}
+	_, err = withContext(ctx, func() (struct{}, error) {
+		return struct{}{}, k.clusterAdmin.CreateTopic(spec.Name, detail, false)
+	})
+	if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		err = lib.NewConflictError(err)
+	}
+	return
+}
@@ -25,6 +25,9 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Nerzal/gocloak/v13"
 	"github.com/SENERGY-Platform/analytics-cleanup/lib"
@@ -32,68 +35,119 @@ import (
 
 type KeycloakService struct {
 	client       *gocloak.GoCloak
-	token        *gocloak.JWT
+	session      *keycloakSession
 	clientId     string
 	clientSecret string
 	realm        string
 	userName     string
 	password     string
 	url          string
+	httpClient   *http.Client
 }
 
-func NewKeycloakService(url string, clientId string, clientSecret string, realm string, userName string, password string) *KeycloakService {
+// keycloakSession holds the token of the service account. It is shared by all copies of KeycloakService.
+type keycloakSession struct {
+	token   *gocloak.JWT
+	expires time.Time
+	mu      sync.Mutex
+}
+
+func NewKeycloakService(url string, clientId string, clientSecret string, realm string, userName string, password string, timeout time.Duration) *KeycloakService {
 	client := gocloak.NewClient(url + "/auth")
-	return &KeycloakService{client, nil, clientId, clientSecret, realm, userName, password, url}
+	client.RestyClient().SetTimeout(timeout)
+	return &KeycloakService{client, &keycloakSession{}, clientId, clientSecret, realm, userName, password, url, &http.Client{Timeout: timeout}}
 }
 
-func (k *KeycloakService) Login() {
-	ctx := context.Background()
-	token, err := k.client.Login(ctx, k.clientId, k.clientSecret, k.realm, k.userName, k.password)
+func (k *KeycloakService) Login(ctx context.Context) {
+	k.session.mu.Lock()
+	defer k.session.mu.Unlock()
+	err := k.login(ctx)
 	if err != nil {
 		fmt.Println("Login failed:" + err.Error())
 	}
-	k.token = token
 }
 
-func (k *KeycloakService) Logout() {
-	ctx := context.Background()
-	err := k.client.Logout(ctx, k.clientId, k.clientSecret, k.realm, k.token.RefreshToken)
+func (k *KeycloakService) Logout(ctx context.Context) {
+	k.session.mu.Lock()
+	defer k.session.mu.Unlock()
+	if k.session.token == nil {
+		return
+	}
+	err := k.client.Logout(ctx, k.clientId, k.clientSecret, k.realm, k.session.token.RefreshToken)
 	if err != nil {
 		fmt.Println("Logout failed:" + err.Error())
 	}
+	k.session.token = nil
 }
 
-func (k *KeycloakService) GetAccessToken() string {
-	return k.token.AccessToken
+func (k *KeycloakService) GetUserInfo(ctx context.Context) (*gocloak.UserInfo, error) {
+	token, err := k.GetAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := k.client.GetUserInfo(ctx, token, k.realm)
+	return user, err
 }
 
-func (k *KeycloakService) GetUserInfo() (*gocloak.UserInfo, error) {
-	ctx := context.Background()
-	user, err := k.client.GetUserInfo(ctx, k.token.AccessToken, k.realm)
-	return user, err
+// GetUserByID returns a lib.NotFoundError if no user with id exists.
+func (k *KeycloakService) GetUserByID(ctx context.Context, id string) (user *gocloak.User, err error) {
+	token, err := k.GetAccessToken(ctx)
+	if err != nil {
+		return
+	}
+	user, err = k.client.GetUserByID(ctx, token, k.realm, id)
+	var apiErr *gocloak.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		err = lib.NewNotFoundError(err)
+	}
+	return
+}
+
+// GetAccessToken returns the token of the service account and logs in again once it expired.
+func (k *KeycloakService) GetAccessToken(ctx context.Context) (token string, err error) {
+	k.session.mu.Lock()
+	defer k.session.mu.Unlock()
+	if k.session.token == nil || time.Now().After(k.session.expires) {
+		err = k.login(ctx)
+		if err != nil {
+			return
+		}
+	}
+	return k.session.token.AccessToken, nil
 }
 
-func (k *KeycloakService) GetUserByID(id string) (user *gocloak.User, err error) {
-	ctx := context.Background()
-	user, err = k.client.GetUserByID(ctx, k.token.AccessToken, k.realm, id)
+func (k *KeycloakService) login(ctx context.Context) (err error) {
+	token, err := k.client.Login(ctx, k.clientId, k.clientSecret, k.realm, k.userName, k.password)
+	if err != nil {
+		return
+	}
+	k.session.token = token
+	// renew the token shortly before it expires
+	k.session.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - 10*time.Second)
 	return
 }
 
-func (k *KeycloakService) GetImpersonateToken(userId string) (token string, err error) {
-	resp, err := http.PostForm(k.url+"/auth/realms/"+k.realm+"/protocol/openid-connect/token", url.Values{
+func (k *KeycloakService) GetImpersonateToken(ctx context.Context, userId string) (token string, err error) {
+	form := url.Values{
 		"client_id":         {k.clientId},
 		"client_secret":     {k.clientSecret},
 		"grant_type":        {"urn:ietf:params:oauth:grant-type:token-exchange"},
 		"requested_subject": {userId},
-	})
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url+"/auth/realms/"+k.realm+"/protocol/openid-connect/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := k.httpClient.Do(req)
 	if err != nil {
 		return
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(resp.Body)
 		log.Println("ERROR: GetUserToken()", resp.StatusCode, string(body))
-		err = errors.New("access denied")
-		return "", resp.Body.Close()
+		return "", errors.New("access denied")
 	}
 	var openIdToken lib.OpenIdToken
 	err = json.NewDecoder(resp.Body).Decode(&openIdToken)
@@ -0,0 +1,257 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes_api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+type Kubernetes struct {
+	client           kubernetes.Interface
+	servingNamespace string
+	pipeNamespace    string
+}
+
+// NewKubernetes creates a driver for the cluster described by restConfig.
+// Pointing restConfig.Host at a fake API server is enough to run it outside a cluster.
+func NewKubernetes(restConfig *rest.Config, servingNamespace string, pipeNamespace string) (*Kubernetes, error) {
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Kubernetes{client, servingNamespace, pipeNamespace}, nil
+}
+
+// GetRestConfig loads the in-cluster credentials if kubeconfig is empty, otherwise the given kubeconfig file.
+func GetRestConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig == "" {
+		return rest.InClusterConfig()
+	}
+	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+}
+
+func (k *Kubernetes) GetServices(ctx context.Context, collection string) (services []lib.KubeService, err error) {
+	namespace := k.getNamespace(collection)
+	workloads, err := k.listWorkloads(ctx, namespace)
+	if err != nil {
+		return
+	}
+	serviceList, err := k.client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		err = errors.New("could not get services: " + err.Error())
+		return
+	}
+	for _, service := range serviceList.Items {
+		if len(service.Spec.Selector) == 0 {
+			// services without selector are not backed by a workload
+			continue
+		}
+		services = append(services, lib.KubeService{
+			Id:                namespace + ":" + service.Name,
+			Name:              service.Name,
+			BaseType:          "service",
+			TargetWorkloadIds: getTargetWorkloadIds(service, workloads),
+		})
+	}
+	return
+}
+
+func (k *Kubernetes) GetWorkloads(ctx context.Context, collection string) (workloads []lib.Workload, err error) {
+	data, err := k.listWorkloads(ctx, k.getNamespace(collection))
+	if err != nil {
+		return
+	}
+	for _, w := range data {
+		workload := lib.Workload{
+			Id:          w.id(),
+			Name:        w.meta.Name,
+			Type:        w.workloadType,
+			Environment: map[string]string{},
+			Labels:      w.meta.Labels,
+		}
+		if containers := w.template.Spec.Containers; len(containers) > 0 {
+			workload.ImageUuid = containers[0].Image
+			workload.Environment = getEnv(containers[0])
+		}
+		workloads = append(workloads, workload)
+	}
+	return
+}
+
+func (k *Kubernetes) GetWorkloadEnvs(ctx context.Context, collection string) (envs []map[string]string, err error) {
+	data, err := k.listWorkloads(ctx, k.getNamespace(collection))
+	if err != nil {
+		return
+	}
+	for _, w := range data {
+		for _, container := range w.template.Spec.Containers {
+			envs = append(envs, getEnv(container))
+		}
+	}
+	return
+}
+
+func (k *Kubernetes) DeleteWorkload(ctx context.Context, workloadId string, collection string) (err error) {
+	namespace := k.getNamespace(collection)
+	workloadType, _, name, ok := lib.ParseWorkloadId(workloadId)
+	if !ok {
+		workloadType, err = k.resolveWorkloadType(ctx, namespace, name)
+		if err != nil {
+			return
+		}
+	}
+	propagation := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+	switch workloadType {
+	case lib.WorkloadTypeDeployment:
+		err = k.client.AppsV1().Deployments(namespace).Delete(ctx, name, opts)
+	case lib.WorkloadTypeStatefulSet:
+		err = k.client.AppsV1().StatefulSets(namespace).Delete(ctx, name, opts)
+	case lib.WorkloadTypeDaemonSet:
+		err = k.client.AppsV1().DaemonSets(namespace).Delete(ctx, name, opts)
+	case lib.WorkloadTypeCronJob:
+		err = k.client.BatchV1().CronJobs(namespace).Delete(ctx, name, opts)
+	case lib.WorkloadTypeJob:
+		err = k.client.BatchV1().Jobs(namespace).Delete(ctx, name, opts)
+	default:
+		return lib.NewInputError(errors.New("unsupported workload type " + workloadType))
+	}
+	if k8serrors.IsNotFound(err) {
+		return lib.NewNotFoundError(err)
+	}
+	if err != nil {
+		err = errors.New("could not delete operator: " + err.Error())
+	}
+	return
+}
+
+func (k *Kubernetes) DeleteService(ctx context.Context, serviceId string, collection string) (err error) {
+	// service ids follow the rancher format <namespace>:<name>
+	parts := strings.Split(serviceId, ":")
+	err = k.client.CoreV1().Services(k.getNamespace(collection)).Delete(ctx, parts[len(parts)-1], metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return lib.NewNotFoundError(err)
+	}
+	if err != nil {
+		err = errors.New("could not delete service: " + err.Error())
+	}
+	return
+}
+
+// workload is the common view on all kubernetes workload kinds.
+type workload struct {
+	workloadType string
+	meta         metav1.ObjectMeta
+	template     corev1.PodTemplateSpec
+}
+
+func (w workload) id() string {
+	return lib.NewWorkloadId(w.workloadType, w.meta.Namespace, w.meta.Name)
+}
+
+func (k *Kubernetes) listWorkloads(ctx context.Context, namespace string) (workloads []workload, err error) {
+	opts := metav1.ListOptions{}
+	deployments, err := k.client.AppsV1().Deployments(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, errors.New("could not get workloads: " + err.Error())
+	}
+	for _, item := range deployments.Items {
+		workloads = append(workloads, workload{lib.WorkloadTypeDeployment, item.ObjectMeta, item.Spec.Template})
+	}
+	statefulSets, err := k.client.AppsV1().StatefulSets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, errors.New("could not get workloads: " + err.Error())
+	}
+	for _, item := range statefulSets.Items {
+		workloads = append(workloads, workload{lib.WorkloadTypeStatefulSet, item.ObjectMeta, item.Spec.Template})
+	}
+	daemonSets, err := k.client.AppsV1().DaemonSets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, errors.New("could not get workloads: " + err.Error())
+	}
+	for _, item := range daemonSets.Items {
+		workloads = append(workloads, workload{lib.WorkloadTypeDaemonSet, item.ObjectMeta, item.Spec.Template})
+	}
+	cronJobs, err := k.client.BatchV1().CronJobs(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, errors.New("could not get workloads: " + err.Error())
+	}
+	for _, item := range cronJobs.Items {
+		workloads = append(workloads, workload{lib.WorkloadTypeCronJob, item.ObjectMeta, item.Spec.JobTemplate.Spec.Template})
+	}
+	jobs, err := k.client.BatchV1().Jobs(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, errors.New("could not get workloads: " + err.Error())
+	}
+	for _, item := range jobs.Items {
+		if len(item.OwnerReferences) > 0 {
+			// jobs spawned by a cronjob belong to their cronjob
+			continue
+		}
+		workloads = append(workloads, workload{lib.WorkloadTypeJob, item.ObjectMeta, item.Spec.Template})
+	}
+	return
+}
+
+func (k *Kubernetes) resolveWorkloadType(ctx context.Context, namespace string, name string) (workloadType string, err error) {
+	workloads, err := k.listWorkloads(ctx, namespace)
+	if err != nil {
+		return
+	}
+	for _, w := range workloads {
+		if w.meta.Name == name {
+			return w.workloadType, nil
+		}
+	}
+	return "", lib.NewNotFoundError(errors.New("workload " + name + " not found"))
+}
+
+func (k *Kubernetes) getNamespace(collection string) string {
+	if collection == lib.SERVING {
+		return k.servingNamespace
+	}
+	return k.pipeNamespace
+}
+
+func getTargetWorkloadIds(service corev1.Service, workloads []workload) (ids []string) {
+	selector := labels.SelectorFromSet(service.Spec.Selector)
+	for _, w := range workloads {
+		if selector.Matches(labels.Set(w.template.Labels)) {
+			ids = append(ids, w.id())
+		}
+	}
+	return
+}
+
+func getEnv(container corev1.Container) map[string]string {
+	env := map[string]string{}
+	for _, v := range container.Env {
+		env[v.Name] = v.Value
+	}
+	return env
+}
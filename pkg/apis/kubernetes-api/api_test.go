@@ -0,0 +1,168 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes_api
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testPipeNamespace    = "pipes"
+	testServingNamespace = "serving"
+)
+
+func newTestDriver(objects ...runtime.Object) *Kubernetes {
+	return &Kubernetes{fake.NewSimpleClientset(objects...), testServingNamespace, testPipeNamespace}
+}
+
+func testTemplate(app string, env ...corev1.EnvVar) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": app}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: app, Image: "image-" + app, Env: env}}},
+	}
+}
+
+func testObjects() []runtime.Object {
+	return []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "op-1", Namespace: testPipeNamespace, Labels: map[string]string{"pipelineId": "p1"}},
+			Spec:       appsv1.DeploymentSpec{Template: testTemplate("op-1", corev1.EnvVar{Name: "PIPELINE_ID", Value: "p1"})},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "op-2", Namespace: testPipeNamespace},
+			Spec:       appsv1.StatefulSetSpec{Template: testTemplate("op-2")},
+		},
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "cron", Namespace: testPipeNamespace},
+			Spec:       batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: testTemplate("cron")}}},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "cron-123", Namespace: testPipeNamespace, OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "cron"}}},
+			Spec:       batchv1.JobSpec{Template: testTemplate("cron")},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "kafka2influx-s1", Namespace: testServingNamespace},
+			Spec:       appsv1.DeploymentSpec{Template: testTemplate("s1")},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "op-1-svc", Namespace: testPipeNamespace},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "op-1"}, ClusterIP: "10.0.0.1"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: testPipeNamespace},
+		},
+	}
+}
+
+func TestGetWorkloads(t *testing.T) {
+	k := newTestDriver(testObjects()...)
+	workloads, err := k.GetWorkloads(context.Background(), lib.PIPELINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, workload := range workloads {
+		ids = append(ids, workload.Id)
+	}
+	slices.Sort(ids)
+	expected := []string{"cronjob:pipes:cron", "deployment:pipes:op-1", "statefulset:pipes:op-2"}
+	if !slices.Equal(ids, expected) {
+		t.Fatalf("expected %v, got %v", expected, ids)
+	}
+	for _, workload := range workloads {
+		if workload.Name == "op-1" {
+			if workload.Environment["PIPELINE_ID"] != "p1" || workload.Labels["pipelineId"] != "p1" || workload.ImageUuid != "image-op-1" {
+				t.Fatalf("unexpected workload %+v", workload)
+			}
+		}
+	}
+	serving, err := k.GetWorkloads(context.Background(), lib.SERVING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serving) != 1 || serving[0].Name != "kafka2influx-s1" {
+		t.Fatalf("unexpected serving workloads %+v", serving)
+	}
+}
+
+func TestGetServices(t *testing.T) {
+	k := newTestDriver(testObjects()...)
+	services, err := k.GetServices(context.Background(), lib.PIPELINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// services without selector are skipped
+	if len(services) != 1 {
+		t.Fatalf("expected one service, got %+v", services)
+	}
+	if services[0].Id != "pipes:op-1-svc" || !slices.Equal(services[0].TargetWorkloadIds, []string{"deployment:pipes:op-1"}) {
+		t.Fatalf("unexpected service %+v", services[0])
+	}
+}
+
+func TestDeleteWorkload(t *testing.T) {
+	k := newTestDriver(testObjects()...)
+	ctx := context.Background()
+	// plain names are resolved to their kind
+	if err := k.DeleteWorkload(ctx, "op-2", lib.PIPELINE); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.DeleteWorkload(ctx, "cronjob:pipes:cron", lib.PIPELINE); err != nil {
+		t.Fatal(err)
+	}
+	workloads, err := k.GetWorkloads(ctx, lib.PIPELINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workloads) != 1 || workloads[0].Name != "op-1" {
+		t.Fatalf("unexpected workloads after delete %+v", workloads)
+	}
+	var notFound *lib.NotFoundError
+	if err = k.DeleteWorkload(ctx, "op-2", lib.PIPELINE); !errors.As(err, &notFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err = k.DeleteWorkload(ctx, "deployment:pipes:missing", lib.PIPELINE); !errors.As(err, &notFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	var input *lib.InputError
+	if err = k.DeleteWorkload(ctx, "pod:pipes:op-1", lib.PIPELINE); !errors.As(err, &input) {
+		t.Fatalf("expected input error, got %v", err)
+	}
+}
+
+func TestDeleteService(t *testing.T) {
+	k := newTestDriver(testObjects()...)
+	ctx := context.Background()
+	if err := k.DeleteService(ctx, "pipes:op-1-svc", lib.PIPELINE); err != nil {
+		t.Fatal(err)
+	}
+	var notFound *lib.NotFoundError
+	if err := k.DeleteService(ctx, "pipes:op-1-svc", lib.PIPELINE); !errors.As(err, &notFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// exportedWorkload is the spec of an exported workload. Object is the workload of Type without server set fields.
+type exportedWorkload struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+func (k *Kubernetes) ExportWorkload(ctx context.Context, workloadId string, collection string) (spec json.RawMessage, err error) {
+	namespace := k.getNamespace(collection)
+	workloadType, _, name, ok := lib.ParseWorkloadId(workloadId)
+	if !ok {
+		workloadType, err = k.resolveWorkloadType(ctx, namespace, name)
+		if err != nil {
+			return
+		}
+	}
+	exported := exportedWorkload{Type: workloadType}
+	switch workloadType {
+	case lib.WorkloadTypeDeployment:
+		exported.Object, err = exportObject(ctx, name, k.client.AppsV1().Deployments(namespace).Get, func(d *appsv1.Deployment) {
+			d.Status = appsv1.DeploymentStatus{}
+		})
+	case lib.WorkloadTypeStatefulSet:
+		exported.Object, err = exportObject(ctx, name, k.client.AppsV1().StatefulSets(namespace).Get, func(s *appsv1.StatefulSet) {
+			s.Status = appsv1.StatefulSetStatus{}
+		})
+	case lib.WorkloadTypeDaemonSet:
+		exported.Object, err = exportObject(ctx, name, k.client.AppsV1().DaemonSets(namespace).Get, func(d *appsv1.DaemonSet) {
+			d.Status = appsv1.DaemonSetStatus{}
+		})
+	case lib.WorkloadTypeCronJob:
+		exported.Object, err = exportObject(ctx, name, k.client.BatchV1().CronJobs(namespace).Get, func(c *batchv1.CronJob) {
+			c.Status = batchv1.CronJobStatus{}
+		})
+	case lib.WorkloadTypeJob:
+		exported.Object, err = exportObject(ctx, name, k.client.BatchV1().Jobs(namespace).Get, func(j *batchv1.Job) {
+			j.Status = batchv1.JobStatus{}
+			// the selector and its labels are generated from the uid of the job, a new job gets new ones
+			j.Spec.Selector = nil
+			for label := range j.Spec.Template.Labels {
+				if strings.HasSuffix(label, "controller-uid") || strings.HasSuffix(label, "job-name") {
+					delete(j.Spec.Template.Labels, label)
+				}
+			}
+		})
+	default:
+		return nil, lib.NewInputError(errors.New("unsupported workload type " + workloadType))
+	}
+	if err != nil {
+		return
+	}
+	return json.Marshal(exported)
+}
+
+func (k *Kubernetes) RestoreWorkload(ctx context.Context, spec json.RawMessage, collection string) (err error) {
+	namespace := k.getNamespace(collection)
+	var exported exportedWorkload
+	if err = json.Unmarshal(spec, &exported); err != nil {
+		return
+	}
+	switch exported.Type {
+	case lib.WorkloadTypeDeployment:
+		return restoreObject(ctx, exported.Object, k.client.AppsV1().Deployments(namespace).Create)
+	case lib.WorkloadTypeStatefulSet:
+		return restoreObject(ctx, exported.Object, k.client.AppsV1().StatefulSets(namespace).Create)
+	case lib.WorkloadTypeDaemonSet:
+		return restoreObject(ctx, exported.Object, k.client.AppsV1().DaemonSets(namespace).Create)
+	case lib.WorkloadTypeCronJob:
+		return restoreObject(ctx, exported.Object, k.client.BatchV1().CronJobs(namespace).Create)
+	case lib.WorkloadTypeJob:
+		return restoreObject(ctx, exported.Object, k.client.BatchV1().Jobs(namespace).Create)
+	}
+	return lib.NewInputError(errors.New("unsupported workload type " + exported.Type))
+}
+
+func (k *Kubernetes) ExportService(ctx context.Context, serviceId string, collection string) (spec json.RawMessage, err error) {
+	parts := strings.Split(serviceId, ":")
+	return exportObject(ctx, parts[len(parts)-1], k.client.CoreV1().Services(k.getNamespace(collection)).Get, func(s *corev1.Service) {
+		s.Status = corev1.ServiceStatus{}
+		// cluster ips are assigned again on creation
+		s.Spec.ClusterIP = ""
+		s.Spec.ClusterIPs = nil
+	})
+}
+
+func (k *Kubernetes) RestoreService(ctx context.Context, spec json.RawMessage, collection string) error {
+	return restoreObject(ctx, spec, k.client.CoreV1().Services(k.getNamespace(collection)).Create)
+}
+
+// exportObject fetches the object name and strips the fields the API server sets.
+func exportObject[T any](ctx context.Context, name string, get func(context.Context, string, metav1.GetOptions) (*T, error), clean func(*T)) (object json.RawMessage, err error) {
+	obj, err := get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, lib.NewNotFoundError(err)
+	}
+	if err != nil {
+		return nil, errors.New("could not export " + name + ": " + err.Error())
+	}
+	if meta, ok := any(obj).(metav1.Object); ok {
+		meta.SetResourceVersion("")
+		meta.SetUID("")
+		meta.SetCreationTimestamp(metav1.Time{})
+		meta.SetGeneration(0)
+		meta.SetManagedFields(nil)
+	}
+	clean(obj)
+	return json.Marshal(obj)
+}
+
+func restoreObject[T any](ctx context.Context, object json.RawMessage, create func(context.Context, *T, metav1.CreateOptions) (*T, error)) error {
+	obj := new(T)
+	if err := json.Unmarshal(object, obj); err != nil {
+		return err
+	}
+	_, err := create(ctx, obj, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		return lib.NewConflictError(err)
+	}
+	if err != nil {
+		return errors.New("could not restore: " + err.Error())
+	}
+	return nil
+}
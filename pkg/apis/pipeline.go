@@ -18,16 +18,14 @@ package apis
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
-	"strings"
 	"time"
 
 	"strconv"
 
 	"github.com/SENERGY-Platform/analytics-cleanup/lib"
-	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
-	"github.com/parnurzeal/gorequest"
 	"github.com/pkg/errors"
 
 	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
@@ -36,56 +34,61 @@ import (
 type PipelineService struct {
 	pipelineUrl string
 	engineUrl   string
+	client      *http.Client
 }
 
-func NewPipelineService(pipelineUrl string, engineUrl string) *PipelineService {
-	return &PipelineService{pipelineUrl: pipelineUrl, engineUrl: engineUrl}
+func NewPipelineService(pipelineUrl string, engineUrl string, timeout time.Duration) *PipelineService {
+	return &PipelineService{pipelineUrl: pipelineUrl, engineUrl: engineUrl, client: &http.Client{Timeout: timeout}}
 }
 
-func (p PipelineService) GetPipelines(userId string, accessToken string) (pipes []pipeModels.Pipeline, err error) {
-	request := gorequest.New()
-	resp, body, errs := request.Get(p.pipelineUrl+"/admin/pipeline").Set("X-UserId", userId).
-		Set("Authorization", "Bearer "+accessToken).End()
-	if len(errs) < 1 {
-		if resp.StatusCode != 200 {
-			return pipes, errors.New("could not access pipeline registry: " + strconv.Itoa(resp.StatusCode) + " " + body)
-		}
-		var data pipeModels.PipelinesResponse
-		err = json.Unmarshal([]byte(body), &data)
-		if err != nil {
-			return
-		}
-		pipes = data.Data
-	} else {
-		err = errors.New(strings.Join(util.ErrorsToStrings(errs), ","))
+func (p PipelineService) GetPipelines(ctx context.Context, userId string, accessToken string) (pipes []pipeModels.Pipeline, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.pipelineUrl+"/admin/pipeline", nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("X-UserId", userId)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	statusCode, body, err := doRequest(p.client, req)
+	if err != nil {
+		return
+	}
+	if statusCode != http.StatusOK {
+		return pipes, errors.New("could not access pipeline registry: " + strconv.Itoa(statusCode) + " " + body)
+	}
+	var data pipeModels.PipelinesResponse
+	err = json.Unmarshal([]byte(body), &data)
+	if err != nil {
+		return
 	}
+	pipes = data.Data
 	return
 }
 
-func (p PipelineService) DeletePipeline(id string, accessToken string) (err error) {
-	request := gorequest.New()
-	resp, body, errs := request.Delete(p.pipelineUrl+"/admin/pipeline/"+id).Set("Authorization", "Bearer "+accessToken).End()
-	if len(errs) > 0 {
-		err = errors.New(strings.Join(util.ErrorsToStrings(errs), ","))
+func (p PipelineService) DeletePipeline(ctx context.Context, id string, accessToken string) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, p.pipelineUrl+"/admin/pipeline/"+id, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	statusCode, body, err := doRequest(p.client, req)
+	if err != nil {
 		return
 	}
-	if resp.StatusCode == http.StatusNotFound {
+	if statusCode == http.StatusNotFound {
 		return lib.NewNotFoundError(errors.New("pipeline " + id + " not found"))
 	}
-	if resp.StatusCode != http.StatusOK {
-		errs = append(errs, errors.New("could not access pipeline registry: "+strconv.Itoa(resp.StatusCode)+" "+body))
+	if statusCode != http.StatusOK {
+		err = errors.New("could not access pipeline registry: " + strconv.Itoa(statusCode) + " " + body)
 	}
 	return
 }
 
-func (p PipelineService) CreatePipeline(instance *lib.PipelineRequest, userId string, userToken string) error {
+func (p PipelineService) CreatePipeline(ctx context.Context, instance *lib.PipelineRequest, userId string, userToken string) error {
 	b, err := json.Marshal(instance)
 	if err != nil {
 		return err
 	}
-
-	println(instance.Name)
-	req, err := http.NewRequest(http.MethodPut, p.engineUrl+"/pipeline", bytes.NewBuffer(b))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.engineUrl+"/pipeline", bytes.NewBuffer(b))
 	if err != nil {
 		return err
 	}
@@ -93,15 +96,13 @@ func (p PipelineService) CreatePipeline(instance *lib.PipelineRequest, userId st
 	req.Header.Set("X-UserId", userId)
 	req.Header.Set("Content-Type", "application/json")
 
-	http.DefaultClient.Timeout = 10 * time.Second
-
-	resp, err := http.DefaultClient.Do(req)
+	statusCode, _, err := doRequest(p.client, req)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return errors.New("unexpected status code " + strconv.Itoa(resp.StatusCode))
+	if statusCode != http.StatusOK {
+		return errors.New("unexpected status code " + strconv.Itoa(statusCode))
 	}
 	return nil
 }
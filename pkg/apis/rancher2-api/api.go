@@ -17,16 +17,22 @@
 package rancher2_api
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/SENERGY-Platform/analytics-cleanup/lib"
-
-	"github.com/parnurzeal/gorequest"
 )
 
+const pageLimit = "1000"
+
 type Rancher2 struct {
 	url                string
 	accessKey          string
@@ -35,148 +41,196 @@ type Rancher2 struct {
 	servingProjectId   string
 	pipeNamespaceId    string
 	pipeProjectId      string
+	client             *http.Client
 }
 
-func NewRancher2(url string, accessKey string, secretKey string, servingNamespaceId string, servingProjectId string, pipeNamespaceId string, pipeProjectId string) *Rancher2 {
-	return &Rancher2{url, accessKey, secretKey, servingNamespaceId, servingProjectId, pipeNamespaceId, pipeProjectId}
+func NewRancher2(url string, accessKey string, secretKey string, servingNamespaceId string, servingProjectId string, pipeNamespaceId string, pipeProjectId string, timeout time.Duration) *Rancher2 {
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	return &Rancher2{url, accessKey, secretKey, servingNamespaceId, servingProjectId, pipeNamespaceId, pipeProjectId, client}
 }
 
-func (r *Rancher2) GetServices(collection string) (services []lib.KubeService, err error) {
-	request := gorequest.New().SetBasicAuth(r.accessKey, r.secretKey).TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
-	request.Get(r.url + "projects/" + r.pipeProjectId + "/services/?limit=2000&namespaceId=" + r.pipeNamespaceId)
-	if collection == "serving" {
-		request.Get(r.url + "projects/" + r.servingProjectId + "/services/?limit=2000&namespaceId=" + r.servingNamespaceId)
-	}
-	resp, body, e := request.End()
-	if resp.StatusCode != http.StatusOK {
-		err = errors.New("could not get services: ")
+func (r *Rancher2) GetServices(ctx context.Context, collection string) (services []lib.KubeService, err error) {
+	u := r.url + "projects/" + r.pipeProjectId + "/services/?limit=" + pageLimit + "&namespaceId=" + r.pipeNamespaceId
+	if collection == lib.SERVING {
+		u = r.url + "projects/" + r.servingProjectId + "/services/?limit=" + pageLimit + "&namespaceId=" + r.servingNamespaceId
+	}
+	data, err := getCollection[Service](ctx, r, u)
+	if err != nil {
+		err = errors.New("could not get services: " + err.Error())
 		return
 	}
-	if len(e) > 0 {
-		err = errors.New("something went wrong")
+	for _, service := range data {
+		services = append(services, lib.KubeService{
+			Id:                service.Id,
+			Name:              service.Name,
+			BaseType:          service.BaseType,
+			TargetWorkloadIds: service.TargetWorkloadIds,
+		})
+	}
+	return
+}
+
+func (r *Rancher2) GetWorkloads(ctx context.Context, collection string) (workloads []lib.Workload, err error) {
+	data, err := r.getWorkloads(ctx, collection)
+	if err != nil {
 		return
 	}
-	var serviceCollection = ServiceCollection{}
-	err = json.Unmarshal([]byte(body), &serviceCollection)
-	if len(serviceCollection.Data) > 1 {
-		for _, service := range serviceCollection.Data {
-			services = append(services, lib.KubeService{
-				Id:                service.Id,
-				Name:              service.Name,
-				BaseType:          service.BaseType,
-				TargetWorkloadIds: service.TargetWorkloadIds,
-			})
+	for _, workload := range data {
+		r2Env := map[string]string{}
+		image := ""
+		if len(workload.Containers) > 0 {
+			for _, v := range workload.Containers[0].Env {
+				r2Env[v.Name] = v.Value
+			}
+			image = workload.Containers[0].Image
 		}
+		workloads = append(workloads, lib.Workload{
+			Id:          workload.Id,
+			Name:        workload.Name,
+			Type:        strings.ToLower(workload.Type),
+			ImageUuid:   image,
+			Environment: r2Env,
+			Labels:      workload.Labels,
+		})
 	}
 	return
 }
 
-func (r *Rancher2) GetWorkloads(collection string) (workloads []lib.Workload, err error) {
-	request := gorequest.New().SetBasicAuth(r.accessKey, r.secretKey).TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
-	request.Get(r.url + "projects/" + r.pipeProjectId + "/workloads/?namespaceId=" + r.pipeNamespaceId)
-	if collection == "serving" {
-		request.Get(r.url + "projects/" + r.servingProjectId + "/workloads/?namespaceId=" + r.servingNamespaceId)
-	}
-	resp, body, e := request.End()
-	if resp.StatusCode != http.StatusOK {
-		err = errors.New("could not get workloads: ")
+func (r *Rancher2) GetWorkloadEnvs(ctx context.Context, collection string) (envs []map[string]string, err error) {
+	data, err := r.getWorkloads(ctx, collection)
+	if err != nil {
 		return
 	}
-	if len(e) > 0 {
-		err = errors.New("something went wrong")
-		return
-	}
-
-	var workloadCollection = WorkloadCollection{}
-	err = json.Unmarshal([]byte(body), &workloadCollection)
-	if len(workloadCollection.Data) > 0 {
-		for _, workload := range workloadCollection.Data {
+	for _, workload := range data {
+		for _, container := range workload.Containers {
 			r2Env := map[string]string{}
-			for _, v := range workload.Containers[0].Env {
+			for _, v := range container.Env {
 				r2Env[v.Name] = v.Value
 			}
-			workloads = append(workloads, lib.Workload{
-				Id:          workload.Id,
-				Name:        workload.Name,
-				ImageUuid:   workload.Containers[0].Image,
-				Environment: r2Env,
-				Labels:      workload.Labels,
-			})
+			envs = append(envs, r2Env)
 		}
 	}
 	return
 }
 
-func (r *Rancher2) GetWorkloadEnvs(collection string) (envs []map[string]string, err error) {
-	request := gorequest.New().SetBasicAuth(r.accessKey, r.secretKey).TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
-	request.Get(r.url + "projects/" + r.pipeProjectId + "/workloads/?namespaceId=" + r.pipeNamespaceId)
-	if collection == "serving" {
-		request.Get(r.url + "projects/" + r.servingProjectId + "/workloads/?namespaceId=" + r.servingNamespaceId)
+func (r *Rancher2) DeleteWorkload(ctx context.Context, workloadId string, collection string) (err error) {
+	if _, _, _, ok := lib.ParseWorkloadId(workloadId); !ok {
+		workloadId, err = r.resolveWorkloadId(ctx, workloadId, collection)
+		if err != nil {
+			return
+		}
 	}
-	resp, body, e := request.End()
-	if resp.StatusCode != http.StatusOK {
-		err = errors.New("could not get services: ")
-		return
+	u := r.url + "projects/" + r.pipeProjectId + "/workloads/" + workloadId
+	if collection == lib.SERVING {
+		u = r.url + "projects/" + r.servingProjectId + "/workloads/" + workloadId
 	}
-	if len(e) > 0 {
-		err = errors.New("something went wrong")
+	statusCode, body, err := r.request(ctx, http.MethodDelete, u)
+	if err != nil {
 		return
 	}
-	var workloadCollection = WorkloadCollection{}
-	err = json.Unmarshal([]byte(body), &workloadCollection)
-	if len(workloadCollection.Data) > 1 {
-		for _, workload := range workloadCollection.Data {
-			for _, container := range workload.Containers {
-				r2Env := map[string]string{}
-				for _, v := range container.Env {
-					r2Env[v.Name] = v.Value
-				}
-				envs = append(envs, r2Env)
-			}
+	if statusCode != http.StatusNoContent {
+		if statusCode == http.StatusNotFound {
+			return lib.NewNotFoundError(errors.New(body))
 		}
+		err = errors.New("could not delete operator: " + body)
+		return
 	}
 	return
 }
 
-func (r *Rancher2) DeleteWorkload(workloadId string, collection string) (err error) {
-	request := gorequest.New().SetBasicAuth(r.accessKey, r.secretKey).TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
-	request.Delete(r.url + "projects/" + r.pipeProjectId + "/workloads/deployment:" + r.pipeNamespaceId + ":" + workloadId)
-	if collection == "serving" {
-		request.Delete(r.url + "projects/" + r.servingProjectId + "/workloads/deployment:" + r.servingNamespaceId + ":" + workloadId)
+func (r *Rancher2) DeleteService(ctx context.Context, serviceId string, collection string) (err error) {
+	u := r.url + "project/" + r.pipeProjectId + "/services/" + serviceId
+	if collection == lib.SERVING {
+		u = r.url + "project/" + r.servingProjectId + "/services/" + serviceId
 	}
-	resp, body, e := request.End()
-	if resp.StatusCode != http.StatusNoContent {
-		if resp.StatusCode == http.StatusNotFound {
-			return lib.NewNotFoundError(errors.New(body))
-		}
-		err = errors.New("could not delete operator: " + body)
+	statusCode, body, err := r.request(ctx, http.MethodDelete, u)
+	if err != nil {
 		return
 	}
-	if len(e) > 0 {
-		err = errors.New("something went wrong")
+	if statusCode != http.StatusNoContent {
+		if statusCode == http.StatusNotFound {
+			return lib.NewNotFoundError(errors.New(body))
+		}
+		err = errors.New("could not delete service: " + body)
 		return
 	}
 	return
 }
 
-func (r *Rancher2) DeleteService(serviceId string, collection string) (err error) {
-	request := gorequest.New().SetBasicAuth(r.accessKey, r.secretKey).TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
-	request.Delete(r.url + "project/" + r.pipeProjectId + "/services/" + serviceId)
-	if collection == "serving" {
-		request.Delete(r.url + "project/" + r.servingProjectId + "/services/" + serviceId)
+func (r *Rancher2) getWorkloads(ctx context.Context, collection string) (workloads []Workload, err error) {
+	u := r.url + "projects/" + r.pipeProjectId + "/workloads/?limit=" + pageLimit + "&namespaceId=" + r.pipeNamespaceId
+	if collection == lib.SERVING {
+		u = r.url + "projects/" + r.servingProjectId + "/workloads/?limit=" + pageLimit + "&namespaceId=" + r.servingNamespaceId
 	}
-	resp, body, e := request.End()
-	if resp.StatusCode != http.StatusNoContent {
-		err = errors.New("could not delete service: " + body)
-		return
+	workloads, err = getCollection[Workload](ctx, r, u)
+	if err != nil {
+		err = errors.New("could not get workloads: " + err.Error())
 	}
-	if len(e) > 0 {
-		err = errors.New("something went wrong")
+	return
+}
+
+// resolveWorkloadId looks up the full workload id of a workload name, independent of the workload type.
+func (r *Rancher2) resolveWorkloadId(ctx context.Context, name string, collection string) (id string, err error) {
+	workloads, err := r.getWorkloads(ctx, collection)
+	if err != nil {
 		return
 	}
+	for _, workload := range workloads {
+		if workload.Name == name {
+			return workload.Id, nil
+		}
+	}
+	return "", lib.NewNotFoundError(errors.New("workload " + name + " not found"))
+}
+
+// getCollection follows the pagination.next links of a rancher collection until all pages are fetched.
+// Any page that can not be fetched fails the whole request, partial results are never returned.
+func getCollection[T any](ctx context.Context, r *Rancher2, u string) (data []T, err error) {
+	visited := map[string]bool{}
+	for u != "" {
+		if visited[u] {
+			return nil, errors.New("pagination loop detected at " + u)
+		}
+		visited[u] = true
+		statusCode, body, err := r.request(ctx, http.MethodGet, u)
+		if err != nil {
+			return nil, err
+		}
+		if statusCode != http.StatusOK {
+			return nil, errors.New("unexpected status code " + strconv.Itoa(statusCode) + " for " + u + ": " + body)
+		}
+		var page Collection[T]
+		err = json.Unmarshal([]byte(body), &page)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, page.Data...)
+		u = page.Pagination.Next
+	}
 	return
 }
 
-func (r *Rancher2) getServingInstanceName(name string) string {
-	return "kafka2influx-" + name
+func (r *Rancher2) request(ctx context.Context, method string, u string) (statusCode int, body string, err error) {
+	return r.send(ctx, method, u, nil)
+}
+
+func (r *Rancher2) send(ctx context.Context, method string, u string, payload []byte) (statusCode int, body string, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(r.accessKey, r.secretKey)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b), err
 }
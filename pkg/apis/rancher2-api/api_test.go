@@ -0,0 +1,148 @@
+/*
+ * Copyright 2019 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rancher2_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+// newTestRancher stands in for the rancher api. pages maps the request uri of every workload page to its
+// response, the next links are relative to the server and completed here.
+func newTestRancher(t *testing.T, pages map[string]Collection[Workload]) *Rancher2 {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "access" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			page, ok := pages[r.URL.RequestURI()]
+			if !ok {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("page not available"))
+				return
+			}
+			if page.Pagination.Next != "" {
+				page.Pagination.Next = server.URL + page.Pagination.Next
+			}
+			_ = json.NewEncoder(w).Encode(page)
+		case http.MethodDelete:
+			if strings.HasSuffix(r.URL.Path, "/deployment:pipes:op-1") || strings.HasSuffix(r.URL.Path, "/pipes:op-1-svc") {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"type":"error","status":"404","message":"not found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return NewRancher2(server.URL+"/v3/", "access", "secret", "serving", "c:serving", "pipes", "c:pipes", time.Second)
+}
+
+const firstPage = "/v3/projects/c:pipes/workloads/?limit=" + pageLimit + "&namespaceId=pipes"
+
+func TestGetWorkloadsPagination(t *testing.T) {
+	r := newTestRancher(t, map[string]Collection[Workload]{
+		firstPage: {
+			Data:       []Workload{{Id: "deployment:pipes:op-1", Name: "op-1", Type: "Deployment", Containers: []Container{{Image: "image-1", Env: []Env{{Name: "PIPELINE_ID", Value: "p1"}}}}}},
+			Pagination: Pagination{Next: "/v3/projects/c:pipes/workloads/?marker=2"},
+		},
+		"/v3/projects/c:pipes/workloads/?marker=2": {
+			Data: []Workload{{Id: "statefulset:pipes:op-2", Name: "op-2", Type: "StatefulSet"}},
+		},
+	})
+	workloads, err := r.GetWorkloads(context.Background(), lib.PIPELINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workloads) != 2 {
+		t.Fatalf("expected workloads of both pages, got %+v", workloads)
+	}
+	if workloads[0].Type != lib.WorkloadTypeDeployment || workloads[0].Environment["PIPELINE_ID"] != "p1" || workloads[0].ImageUuid != "image-1" {
+		t.Fatalf("unexpected workload %+v", workloads[0])
+	}
+	if workloads[1].Type != lib.WorkloadTypeStatefulSet {
+		t.Fatalf("unexpected workload %+v", workloads[1])
+	}
+}
+
+func TestGetWorkloadsPaginationLoop(t *testing.T) {
+	r := newTestRancher(t, map[string]Collection[Workload]{
+		firstPage: {
+			Data:       []Workload{{Id: "deployment:pipes:op-1", Name: "op-1"}},
+			Pagination: Pagination{Next: "/v3/projects/c:pipes/workloads/?marker=2"},
+		},
+		"/v3/projects/c:pipes/workloads/?marker=2": {
+			Data:       []Workload{{Id: "deployment:pipes:op-2", Name: "op-2"}},
+			Pagination: Pagination{Next: firstPage},
+		},
+	})
+	workloads, err := r.GetWorkloads(context.Background(), lib.PIPELINE)
+	if err == nil || !strings.Contains(err.Error(), "pagination loop") {
+		t.Fatalf("expected pagination loop, got %v", err)
+	}
+	if workloads != nil {
+		t.Fatalf("partial results returned %+v", workloads)
+	}
+}
+
+func TestGetWorkloadsPageFailure(t *testing.T) {
+	r := newTestRancher(t, map[string]Collection[Workload]{
+		firstPage: {
+			Data:       []Workload{{Id: "deployment:pipes:op-1", Name: "op-1"}},
+			Pagination: Pagination{Next: "/v3/projects/c:pipes/workloads/?marker=missing"},
+		},
+	})
+	workloads, err := r.GetWorkloads(context.Background(), lib.PIPELINE)
+	if err == nil || workloads != nil {
+		t.Fatalf("expected failure without partial results, got %+v %v", workloads, err)
+	}
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	r := newTestRancher(t, map[string]Collection[Workload]{
+		firstPage: {Data: []Workload{{Id: "deployment:pipes:op-1", Name: "op-1"}}},
+	})
+	ctx := context.Background()
+	// plain names are resolved to the workload id
+	if err := r.DeleteWorkload(ctx, "op-1", lib.PIPELINE); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteService(ctx, "pipes:op-1-svc", lib.PIPELINE); err != nil {
+		t.Fatal(err)
+	}
+	var notFound *lib.NotFoundError
+	if err := r.DeleteWorkload(ctx, "op-2", lib.PIPELINE); !errors.As(err, &notFound) {
+		t.Fatalf("expected not found for unknown name, got %v", err)
+	}
+	if err := r.DeleteWorkload(ctx, "deployment:pipes:op-2", lib.PIPELINE); !errors.As(err, &notFound) {
+		t.Fatalf("expected not found for unknown id, got %v", err)
+	}
+	if err := r.DeleteService(ctx, "pipes:op-2-svc", lib.PIPELINE); !errors.As(err, &notFound) {
+		t.Fatalf("expected not found for unknown service, got %v", err)
+	}
+}
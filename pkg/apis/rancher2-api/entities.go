@@ -60,20 +60,28 @@ type Node struct {
 	RequireAll []string `json:"requireAll,omitempty"`
 }
 
-type WorkloadCollection struct {
-	Data []Workload `json:"data"`
+type Pagination struct {
+	Next  string `json:"next,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+	Total int    `json:"total,omitempty"`
 }
 
+type Collection[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type WorkloadCollection = Collection[Workload]
+
 type Workload struct {
 	Id         string            `json:"id,omitempty"`
 	Name       string            `json:"name,omitempty"`
+	Type       string            `json:"type,omitempty"`
 	Labels     map[string]string `json:"labels,omitempty"`
 	Containers []Container       `json:"containers,omitempty"`
 }
 
-type ServiceCollection struct {
-	Data []Service `json:"data"`
-}
+type ServiceCollection = Collection[Service]
 
 type Service struct {
 	Id                string   `json:"id,omitempty"`
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rancher2_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+// serverFields are set by rancher and rejected or ignored on creation.
+var serverFields = []string{"id", "uuid", "created", "createdTS", "state", "transitioning", "transitioningMessage", "links", "actions", "publicEndpoints", "workloadMetrics"}
+
+func (r *Rancher2) ExportWorkload(ctx context.Context, workloadId string, collection string) (spec json.RawMessage, err error) {
+	if _, _, _, ok := lib.ParseWorkloadId(workloadId); !ok {
+		workloadId, err = r.resolveWorkloadId(ctx, workloadId, collection)
+		if err != nil {
+			return
+		}
+	}
+	return r.export(ctx, r.projectUrl(collection)+"/workloads/"+workloadId)
+}
+
+func (r *Rancher2) RestoreWorkload(ctx context.Context, spec json.RawMessage, collection string) error {
+	return r.restore(ctx, r.projectUrl(collection)+"/workloads", spec)
+}
+
+func (r *Rancher2) ExportService(ctx context.Context, serviceId string, collection string) (spec json.RawMessage, err error) {
+	// cluster ips are assigned again on creation
+	return r.export(ctx, r.projectUrl(collection)+"/services/"+serviceId, "clusterIp", "clusterIPs")
+}
+
+func (r *Rancher2) RestoreService(ctx context.Context, spec json.RawMessage, collection string) error {
+	return r.restore(ctx, r.projectUrl(collection)+"/services", spec)
+}
+
+// export fetches the resource at u and drops the server set fields, the status fields and the given fields.
+func (r *Rancher2) export(ctx context.Context, u string, fields ...string) (spec json.RawMessage, err error) {
+	statusCode, body, err := r.request(ctx, http.MethodGet, u)
+	if err != nil {
+		return
+	}
+	if statusCode == http.StatusNotFound {
+		return nil, lib.NewNotFoundError(errors.New(body))
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.New("could not export " + u + ": " + body)
+	}
+	var resource map[string]any
+	if err = json.Unmarshal([]byte(body), &resource); err != nil {
+		return
+	}
+	for field := range resource {
+		if strings.HasSuffix(field, "Status") {
+			delete(resource, field)
+		}
+	}
+	for _, field := range append(serverFields, fields...) {
+		delete(resource, field)
+	}
+	return json.Marshal(resource)
+}
+
+func (r *Rancher2) restore(ctx context.Context, u string, spec json.RawMessage) (err error) {
+	statusCode, body, err := r.send(ctx, http.MethodPost, u, spec)
+	if err != nil {
+		return
+	}
+	if statusCode == http.StatusConflict {
+		return lib.NewConflictError(errors.New(body))
+	}
+	if statusCode != http.StatusCreated && statusCode != http.StatusOK {
+		return errors.New("could not restore: " + body)
+	}
+	return
+}
+
+func (r *Rancher2) projectUrl(collection string) string {
+	if collection == lib.SERVING {
+		return r.url + "projects/" + r.servingProjectId
+	}
+	return r.url + "projects/" + r.pipeProjectId
+}
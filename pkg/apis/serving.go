@@ -0,0 +1,60 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/pkg/errors"
+)
+
+type ServingService struct {
+	url    string
+	client *http.Client
+}
+
+func NewServingService(url string, timeout time.Duration) *ServingService {
+	return &ServingService{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (s ServingService) GetInstances(ctx context.Context, userId string, accessToken string) (instances []lib.ServingInstance, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"/admin/instance", nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("X-UserId", userId)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	statusCode, body, err := doRequest(s.client, req)
+	if err != nil {
+		return
+	}
+	if statusCode != http.StatusOK {
+		return instances, errors.New("could not access serving registry: " + strconv.Itoa(statusCode) + " " + body)
+	}
+	var data lib.ServingInstancesResponse
+	err = json.Unmarshal([]byte(body), &data)
+	if err != nil {
+		return
+	}
+	instances = data.Data
+	return
+}
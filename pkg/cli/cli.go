@@ -0,0 +1,208 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/service"
+)
+
+const (
+	CommandList     = "list"
+	CommandDelete   = "delete"
+	CommandRecreate = "recreate"
+)
+
+// kindAliases group the orphan kinds behind the short names accepted by --kind.
+var kindAliases = map[string][]string{
+	"workloads":      {service.OrphanKindAnalyticsWorkloads, service.OrphanKindServingWorkloads},
+	"services":       {service.OrphanKindKubeServices, service.OrphanKindServingKubeServices},
+	"topics":         {service.OrphanKindKafkaTopics, service.OrphanKindKafkaOutputTopics},
+	"pipelines":      {service.OrphanKindPipelines},
+	"consumergroups": {service.OrphanKindKafkaConsumerGroups},
+}
+
+// recreatePollInterval is how often the recreate command checks whether the background recreation finished.
+const recreatePollInterval = time.Second
+
+type options struct {
+	kinds       string
+	output      string
+	userId      string
+	pipelineIds string
+	dryRun      bool
+}
+
+// Run executes command with args once and writes the result to out. The output is written even if some resources
+// failed, the failures are returned afterward.
+func Run(ctx context.Context, cs *service.CleanupService, command string, args []string, out io.Writer) (err error) {
+	var run func(context.Context, *service.CleanupService, options, string, string, io.Writer) error
+	switch command {
+	case CommandList:
+		run = list
+	case CommandDelete:
+		run = remove
+	case CommandRecreate:
+		run = recreate
+	default:
+		return errors.New("unknown command " + command + ", expected " + CommandList + ", " + CommandDelete + " or " + CommandRecreate)
+	}
+	opts, err := parseOptions(command, args)
+	if err != nil {
+		return
+	}
+	userId, token, err := cs.GetServiceCredentials(ctx)
+	if err != nil {
+		return
+	}
+	return run(ctx, cs, opts, userId, token, out)
+}
+
+func parseOptions(command string, args []string) (opts options, err error) {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.StringVar(&opts.kinds, "kind", "", "comma separated resource kinds: workloads, services, topics, pipelines, consumergroups or "+strings.Join(service.OrphanKinds, ", "))
+	flags.StringVar(&opts.output, "output", "table", "output format: table or json")
+	flags.StringVar(&opts.userId, "user", "", "recreate: only recreate pipelines of this user")
+	flags.StringVar(&opts.pipelineIds, "pipelines", "", "recreate: comma separated pipeline ids to recreate")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "delete: only report what would be deleted")
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+	if opts.output != outputTable && opts.output != outputJson {
+		return opts, errors.New("unknown output format " + opts.output)
+	}
+	return
+}
+
+// resolveKinds expands the aliases in kinds. Without kinds, all orphan kinds are returned.
+func resolveKinds(kinds string) (resolved []string, err error) {
+	if kinds == "" {
+		return service.OrphanKinds, nil
+	}
+	for _, kind := range strings.Split(kinds, ",") {
+		kind = strings.TrimSpace(kind)
+		if alias, ok := kindAliases[kind]; ok {
+			resolved = append(resolved, alias...)
+		} else if slices.Contains(service.OrphanKinds, kind) {
+			resolved = append(resolved, kind)
+		} else {
+			return nil, errors.New("unknown kind " + kind)
+		}
+	}
+	return
+}
+
+func list(ctx context.Context, cs *service.CleanupService, opts options, userId string, token string, out io.Writer) error {
+	kinds, err := resolveKinds(opts.kinds)
+	if err != nil {
+		return err
+	}
+	entries := []lib.OrphanEntry{}
+	var errs []error
+	for _, kind := range kinds {
+		found, err := cs.DetectOrphans(ctx, kind, userId, token)
+		if err != nil {
+			errs = append(errs, errors.New(kind+": "+err.Error()))
+			continue
+		}
+		entries = append(entries, found...)
+	}
+	if err = writeOrphans(out, opts.output, entries); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+func remove(ctx context.Context, cs *service.CleanupService, opts options, userId string, token string, out io.Writer) error {
+	// deleting every kind at once is too easy to trigger by accident
+	if opts.kinds == "" {
+		return errors.New("delete requires --kind")
+	}
+	kinds, err := resolveKinds(opts.kinds)
+	if err != nil {
+		return err
+	}
+	if opts.dryRun {
+		ctx = service.WithDryRun(ctx)
+	}
+	results := []lib.JobResult{}
+	var errs []error
+	for _, kind := range kinds {
+		_, deleted, err := cs.DeleteOrphans(ctx, kind, userId, token)
+		if err != nil {
+			errs = append(errs, errors.New(kind+": "+err.Error()))
+			continue
+		}
+		for _, result := range deleted {
+			results = append(results, lib.JobResult(result))
+		}
+	}
+	for _, result := range results {
+		if result.Status == lib.DeleteResultFailed {
+			errs = append(errs, errors.New(result.Kind+" "+result.Id+": "+result.Error))
+		}
+	}
+	if err = writeJobResults(out, opts.output, results); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// recreate starts the background recreation and waits until it finished.
+func recreate(ctx context.Context, cs *service.CleanupService, opts options, userId string, token string, out io.Writer) error {
+	request := lib.RecreateRequest{UserId: opts.userId}
+	if opts.pipelineIds != "" {
+		for _, id := range strings.Split(opts.pipelineIds, ",") {
+			request.PipelineIds = append(request.PipelineIds, strings.TrimSpace(id))
+		}
+	}
+	job, err := cs.RecreatePipelines(ctx, request, userId, token)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(recreatePollInterval)
+	defer ticker.Stop()
+	for job.Status == lib.JobStatusRunning {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		job, err = cs.GetJob(job.Id)
+		if err != nil {
+			return err
+		}
+	}
+	var errs []error
+	for _, result := range job.Results {
+		if result.Status == lib.DeleteResultFailed {
+			errs = append(errs, errors.New("pipeline "+result.Id+": "+result.Error))
+		}
+	}
+	if err = writeJobResults(out, opts.output, job.Results); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+const (
+	outputTable = "table"
+	outputJson  = "json"
+)
+
+func writeJson(out io.Writer, v any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeTable(out io.Writer, header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func writeOrphans(out io.Writer, format string, entries []lib.OrphanEntry) error {
+	if format == outputJson {
+		return writeJson(out, entries)
+	}
+	var rows [][]string
+	for _, entry := range entries {
+		rows = append(rows, []string{
+			entry.Kind,
+			entry.Id,
+			entry.FirstSeen.Format(time.RFC3339),
+			fmt.Sprint(entry.Scans),
+			fmt.Sprint(entry.Deletable),
+		})
+	}
+	return writeTable(out, []string{"KIND", "ID", "FIRST SEEN", "SCANS", "DELETABLE"}, rows)
+}
+
+func writeJobResults(out io.Writer, format string, results []lib.JobResult) error {
+	if format == outputJson {
+		return writeJson(out, results)
+	}
+	var rows [][]string
+	for _, result := range results {
+		rows = append(rows, []string{result.Kind, result.Id, result.Status, result.Reason, result.Error})
+	}
+	return writeTable(out, []string{"KIND", "ID", "STATUS", "REASON", "ERROR"}, rows)
+}
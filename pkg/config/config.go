@@ -17,7 +17,10 @@
 package config
 
 import (
+	"time"
+
 	sb_config_hdl "github.com/SENERGY-Platform/go-service-base/config-hdl"
+	sb_config_types "github.com/SENERGY-Platform/go-service-base/config-hdl/types"
 )
 
 type LoggerConfig struct {
@@ -40,27 +43,77 @@ type Rancher2Config struct {
 	PipelineProjectId   string `json:"pipe_project_id" env_var:"RANCHER2_PIPELINE_PROJECT_ID"`
 	PipelineNamespaceId string `json:"pipe_namespace_id" env_var:"RANCHER2_PIPELINE_NAMESPACE_ID"`
 	ServingProjectId    string `json:"serv_project_id" env_var:"RANCHER2_SERVING_PROJECT_ID"`
+	ServingNamespaceId  string `json:"serv_namespace_id" env_var:"RANCHER2_SERVING_NAMESPACE_ID"`
+}
+
+type KubernetesConfig struct {
+	Kubeconfig        string `json:"kubeconfig" env_var:"KUBERNETES_KUBECONFIG"`
+	PipelineNamespace string `json:"pipe_namespace" env_var:"KUBERNETES_PIPELINE_NAMESPACE"`
+	ServingNamespace  string `json:"serv_namespace" env_var:"KUBERNETES_SERVING_NAMESPACE"`
+}
+
+type DockerConfig struct {
+	Host            string `json:"host" env_var:"DOCKER_HOST"`
+	PipelineProject string `json:"pipe_project" env_var:"DOCKER_PIPELINE_PROJECT"`
+	ServingProject  string `json:"serv_project" env_var:"DOCKER_SERVING_PROJECT"`
+}
+
+type ClusterConfig struct {
+	Name             string           `json:"name"`
+	Driver           string           `json:"driver"`
+	Rancher2Config   Rancher2Config   `json:"rancher2"`
+	KubernetesConfig KubernetesConfig `json:"kubernetes"`
+	DockerConfig     DockerConfig     `json:"docker"`
+}
+
+// ScheduledTaskConfig selects a resource kind for scheduled cleanup runs. Without Delete, orphans are only detected.
+type ScheduledTaskConfig struct {
+	Kind   string `json:"kind"`
+	Delete bool   `json:"delete"`
 }
 
 type Config struct {
-	Logger                LoggerConfig   `json:"logger" env_var:"LOGGER_CONFIG"`
-	URLPrefix             string         `json:"url_prefix" env_var:"URL_PREFIX"`
-	ServerPort            int            `json:"server_port" env_var:"SERVER_PORT"`
-	Debug                 bool           `json:"debug" env_var:"DEBUG"`
-	Keycloak              KeycloakConfig `json:"keycloak"`
-	PipelineApiEndpoint   string         `json:"pipeline_api_endpoint" env_var:"PIPELINE_API_ENDPOINT"`
-	FlowEngineApiEndpoint string         `json:"flow_engine_api_endpoint" env_var:"FLOW_ENGINE_API_ENDPOINT"`
-	KafkaBootstrap        string         `json:"kafka_bootstrap" env_var:"KAFKA_BOOTSTRAP"`
-	Mode                  string         `json:"mode" env_var:"MODE"`
-	CronSchedule          string         `json:"cron_schedule" env_var:"CRON_SCHEDULE"`
-	Driver                string         `json:"driver" env_var:"DRIVER"`
-	Rancher2Config        Rancher2Config `json:"rancher2" env_var:"RANCHER2_CONFIG"`
+	Logger                LoggerConfig           `json:"logger" env_var:"LOGGER_CONFIG"`
+	URLPrefix             string                 `json:"url_prefix" env_var:"URL_PREFIX"`
+	ServerPort            int                    `json:"server_port" env_var:"SERVER_PORT"`
+	Debug                 bool                   `json:"debug" env_var:"DEBUG"`
+	HttpTimeout           time.Duration          `json:"http_timeout" env_var:"HTTP_TIMEOUT"`
+	Keycloak              KeycloakConfig         `json:"keycloak"`
+	PipelineApiEndpoint   string                 `json:"pipeline_api_endpoint" env_var:"PIPELINE_API_ENDPOINT"`
+	FlowEngineApiEndpoint string                 `json:"flow_engine_api_endpoint" env_var:"FLOW_ENGINE_API_ENDPOINT"`
+	ServingApiEndpoint    string                 `json:"serving_api_endpoint" env_var:"SERVING_API_ENDPOINT"`
+	FlowRepoApiEndpoint   string                 `json:"flow_repo_api_endpoint" env_var:"FLOW_REPO_API_ENDPOINT"`
+	KafkaBootstrap        string                 `json:"kafka_bootstrap" env_var:"KAFKA_BOOTSTRAP"`
+	Mode                  string                 `json:"mode" env_var:"MODE"`
+	CronSchedule          string                 `json:"cron_schedule" env_var:"CRON_SCHEDULE"`
+	ScheduledTasks        []ScheduledTaskConfig  `json:"scheduled_tasks" env_var:"SCHEDULED_TASKS"`
+	Driver                string                 `json:"driver" env_var:"DRIVER"`
+	Rancher2Config        Rancher2Config         `json:"rancher2" env_var:"RANCHER2_CONFIG"`
+	KubernetesConfig      KubernetesConfig       `json:"kubernetes" env_var:"KUBERNETES_CONFIG"`
+	DockerConfig          DockerConfig           `json:"docker" env_var:"DOCKER_CONFIG"`
+	Clusters              []ClusterConfig        `json:"clusters" env_var:"CLUSTERS"`
+	OwnershipRules        []string               `json:"ownership_rules" env_var:"OWNERSHIP_RULES"`
+	OperatorTopicPrefix   string                 `json:"operator_topic_prefix" env_var:"OPERATOR_TOPIC_PREFIX"`
+	DataDir               string                 `json:"data_dir" env_var:"DATA_DIR"`
+	OrphanGracePeriod     time.Duration          `json:"orphan_grace_period" env_var:"ORPHAN_GRACE_PERIOD"`
+	OrphanMinScans        int                    `json:"orphan_min_scans" env_var:"ORPHAN_MIN_SCANS"`
+	OrphanScanInterval    time.Duration          `json:"orphan_scan_interval" env_var:"ORPHAN_SCAN_INTERVAL"`
+	ReportSigningKey      sb_config_types.Secret `json:"report_signing_key" env_var:"REPORT_SIGNING_KEY"`
+	JobHistory            int                    `json:"job_history" env_var:"JOB_HISTORY"`
+	ResumeInterruptedJobs bool                   `json:"resume_interrupted_jobs" env_var:"RESUME_INTERRUPTED_JOBS"`
+	PlanApprovals         int                    `json:"plan_approvals" env_var:"PLAN_APPROVALS"`
+	PlanApprovalThreshold int                    `json:"plan_approval_threshold" env_var:"PLAN_APPROVAL_THRESHOLD"`
+	PlanExpiry            time.Duration          `json:"plan_expiry" env_var:"PLAN_EXPIRY"`
+	DryRun                bool                   `json:"dry_run" env_var:"DRY_RUN"`
+	Quarantine            bool                   `json:"quarantine" env_var:"QUARANTINE"`
+	QuarantineRetention   time.Duration          `json:"quarantine_retention" env_var:"QUARANTINE_RETENTION"`
 }
 
 func New(path string) (*Config, error) {
 	cfg := Config{
 		ServerPort:     8000,
 		Debug:          false,
+		HttpTimeout:    30 * time.Second,
 		Mode:           "web",
 		KafkaBootstrap: "localhost:9092",
 		Keycloak: KeycloakConfig{
@@ -68,9 +121,27 @@ func New(path string) (*Config, error) {
 			ClientId:     "local",
 			ClientSecret: "local",
 		},
-		Driver: "rancher2",
+		Driver:              "rancher2",
+		OperatorTopicPrefix: "analytics-",
+		DataDir:             "data",
+		OrphanGracePeriod:   1 * time.Hour,
+		OrphanMinScans:      2,
+		OrphanScanInterval:  10 * time.Minute,
+		JobHistory:          100,
+		PlanExpiry:          24 * time.Hour,
+		QuarantineRetention: 7 * 24 * time.Hour,
 		Rancher2Config: Rancher2Config{
 			PipelineNamespaceId: "analytics-pipelines",
+			ServingNamespaceId:  "analytics-serving",
+		},
+		KubernetesConfig: KubernetesConfig{
+			PipelineNamespace: "analytics-pipelines",
+			ServingNamespace:  "analytics-serving",
+		},
+		DockerConfig: DockerConfig{
+			Host:            "unix:///var/run/docker.sock",
+			PipelineProject: "analytics-pipelines",
+			ServingProject:  "analytics-serving",
 		},
 	}
 	err := sb_config_hdl.Load(&cfg, nil, envTypeParser, nil, path)
@@ -23,9 +23,17 @@ import (
 var ConfPath string
 var Deploy bool
 
+// Command and CommandArgs hold the subcommand and its arguments for the cli mode, e.g. "list --kind topics".
+var Command string
+var CommandArgs []string
+
 func ParseFlags() {
 	flag.StringVar(&ConfPath, "config", "", "path to config JSON file")
 	flag.BoolVar(&Deploy, "deploy", false, "deploy certificates and exit")
 	flag.Parse()
+	Command = flag.Arg(0)
+	if flag.NArg() > 1 {
+		CommandArgs = flag.Args()[1:]
+	}
 	return
 }
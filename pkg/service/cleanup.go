@@ -19,89 +19,67 @@ package service
 import (
 	"context"
 	"errors"
-	"log"
-	"sync"
-	"time"
 
-	"github.com/Nerzal/gocloak/v13"
 	"github.com/SENERGY-Platform/analytics-cleanup/lib"
 	"github.com/SENERGY-Platform/analytics-cleanup/pkg/apis"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/config"
 	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
 
 	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
 )
 
 type CleanupService struct {
-	keycloak      apis.KeycloakService
-	driver        Driver
-	pipeline      apis.PipelineService
-	logger        util.FileLogger
-	kafkaAdmin    *apis.KafkaAdmin
-	ctx           context.Context
-	deleteCancel  context.CancelFunc
-	deleteRunning bool
-	deleteStatus  lib.DeleteStatus
-	deleteMu      sync.Mutex
+	keycloak   apis.KeycloakService
+	driver     Driver
+	pipeline   apis.PipelineService
+	flowRepo   apis.FlowRepoService
+	serving    apis.ServingService
+	ownership  *OwnershipMatcher
+	tracker    *OrphanTracker
+	logger     util.FileLogger
+	kafkaAdmin *apis.KafkaAdmin
+	ctx        context.Context
+	config     *config.Config
+	jobs       *JobManager
+	plans      *PlanStore
+	quarantine *QuarantineStore
+	scheduler  schedulerState
 }
 
 const DividerString = "++++++++++++++++++++++++++++++++++++++++++++++++++++++++"
 
-func NewCleanupService(keycloak apis.KeycloakService, driver Driver, pipeline apis.PipelineService, logger util.FileLogger, kafkaAdmin *apis.KafkaAdmin, ctx context.Context) *CleanupService {
+func NewCleanupService(cfg *config.Config, keycloak apis.KeycloakService, driver Driver, pipeline apis.PipelineService, flowRepo apis.FlowRepoService, serving apis.ServingService, ownership *OwnershipMatcher, tracker *OrphanTracker, jobs *JobManager, plans *PlanStore, quarantine *QuarantineStore, logger util.FileLogger, kafkaAdmin *apis.KafkaAdmin, ctx context.Context) *CleanupService {
 	return &CleanupService{
 		keycloak:   keycloak,
 		driver:     driver,
 		pipeline:   pipeline,
+		flowRepo:   flowRepo,
+		serving:    serving,
+		ownership:  ownership,
+		tracker:    tracker,
+		jobs:       jobs,
+		plans:      plans,
+		quarantine: quarantine,
 		logger:     logger,
 		kafkaAdmin: kafkaAdmin,
 		ctx:        ctx,
+		config:     cfg,
 	}
 }
 
-func (cs *CleanupService) StartCleanupService(recreatePipes bool) (err error) {
-	/****************************
-	Check analytics pipelines
-	****************************/
-	if recreatePipes {
-		var info *gocloak.UserInfo
-		info, err = cs.keycloak.GetUserInfo()
-		if err != nil {
-			return
-		}
-
-		var pipes []pipeModels.Pipeline
-		pipes, err = cs.pipeline.GetPipelines(*info.Sub, cs.keycloak.GetAccessToken())
-		if err != nil {
-			return
-		}
-
-		workloads, _ := cs.driver.GetWorkloads(lib.PIPELINE)
-
-		err = cs.recreatePipelines(pipes, workloads)
-		if err != nil {
-			log.Fatal("recreatePipelines failed: " + err.Error())
-		}
-	}
-	return
-	/*
-		cs.deleteOrphanedPipelineServices()
-		cs.deleteOrphanedAnalyticsWorkloads()
-		cs.deleteOrphanedKubeServices(PIPELINE)
-		cs.deleteOrphanedKafkaTopics()
-	*/
-}
-
-func (cs *CleanupService) GetOrphanedPipelineServices(userId string, authToken string) (orphanedPipelineWorkloads []pipeModels.Pipeline, err error) {
+func (cs *CleanupService) GetOrphanedPipelineServices(ctx context.Context, userId string, authToken string) (orphans []lib.Orphan[pipeModels.Pipeline], err error) {
 	var pipes []pipeModels.Pipeline
-	pipes, err = cs.pipeline.GetPipelines(userId, authToken)
+	pipes, err = cs.pipeline.GetPipelines(ctx, userId, authToken)
 	if err != nil {
 		return
 	}
-	workloads, err := cs.driver.GetWorkloads(lib.PIPELINE)
+	workloads, err := cs.driver.GetWorkloads(ctx, lib.PIPELINE)
 	if err != nil {
 		return
 	}
+	var orphanedPipelineWorkloads []pipeModels.Pipeline
 	for _, pipe := range pipes {
-		if !pipeInWorkloads(pipe, workloads) {
+		if !cs.ownership.pipeInWorkloads(pipe, workloads) {
 			deletePipe := true
 
 			for _, operator := range pipe.Operators {
@@ -116,222 +94,480 @@ func (cs *CleanupService) GetOrphanedPipelineServices(userId string, authToken s
 		}
 
 	}
+	orphans = trackOrphans(cs.tracker, OrphanKindPipelines, userId, orphanedPipelineWorkloads, pipelineKey)
+	return
+}
+
+func (cs *CleanupService) DeleteOrphanedPipelineService(ctx context.Context, id string, userId string, accessToken string) error {
+	return cs.deletePipelineById(ctx, id, userId, accessToken)
+}
+
+// DeleteOrphanedPipelineServices starts a job deleting all pipelines that passed the orphan grace period.
+func (cs *CleanupService) DeleteOrphanedPipelineServices(ctx context.Context, userId string, authToken string) (job lib.Job, err error) {
+	orphans, err := cs.GetOrphanedPipelineServices(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	items := deleteJobItems(lib.ResourceKindPipeline, orphans, pipelineKey, func(ctx context.Context, id string) error {
+		token, err := cs.keycloak.GetAccessToken(ctx)
+		if err != nil {
+			return err
+		}
+		return cs.DeleteOrphanedPipelineService(ctx, id, userId, token)
+	})
+	return cs.startJob(ctx, OrphanKindPipelines, userId, items)
+}
+
+// GetOwnerlessPipelines returns pipelines whose owner no longer exists in keycloak or is disabled.
+func (cs *CleanupService) GetOwnerlessPipelines(ctx context.Context, userId string, authToken string) (orphans []lib.Orphan[lib.OwnerlessPipeline], err error) {
+	_, orphans, err = cs.getOwnerlessPipelines(ctx, userId, authToken)
+	return
+}
+
+// DeleteOwnerlessPipelines starts a job deleting all ownerless pipelines that passed the orphan grace period
+// together with their workloads, services and kafka topics. A failing pipeline does not stop the others.
+func (cs *CleanupService) DeleteOwnerlessPipelines(ctx context.Context, userId string, authToken string) (job lib.Job, err error) {
+	pipes, orphans, err := cs.getOwnerlessPipelines(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	ownerless := deletableOrphans(orphans)
+	var items []jobItem
+	if len(ownerless) > 0 {
+		items, err = cs.ownerlessDeleteItems(ctx, pipes, ownerless, userId, authToken)
+		if err != nil {
+			return
+		}
+	}
+	return cs.startJob(ctx, OrphanKindOwnerlessPipelines, userId, items)
+}
+
+func (cs *CleanupService) ownerlessDeleteItems(ctx context.Context, pipes []pipeModels.Pipeline, ownerless []lib.OwnerlessPipeline, userId string, authToken string) (items []jobItem, err error) {
+	instances, err := cs.serving.GetInstances(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	workloads, err := cs.driver.GetWorkloads(ctx, lib.PIPELINE)
+	if err != nil {
+		return
+	}
+	services, err := cs.driver.GetServices(ctx, lib.PIPELINE)
+	if err != nil {
+		return
+	}
+	topics, err := cs.kafkaAdmin.GetTopics(ctx)
+	if err != nil {
+		return
+	}
+	deleteIds := map[string]bool{}
+	for _, o := range ownerless {
+		deleteIds[o.Pipeline.Id] = true
+	}
+	var remainingPipes []pipeModels.Pipeline
+	for _, pipe := range pipes {
+		if !deleteIds[pipe.Id] {
+			remainingPipes = append(remainingPipes, pipe)
+		}
+	}
+	expected := getExpectedTopics(remainingPipes, instances)
+	for _, o := range ownerless {
+		deletion := cs.newPipelineDeletion(o.Pipeline, workloads, services)
+		deletion.topics = getPipelineTopics(o.Pipeline, topics, expected)
+		items = append(items, jobItem{JobItem: lib.JobItem{Kind: lib.ResourceKindPipeline, Id: o.Pipeline.Id}, run: func(ctx context.Context) lib.JobResult {
+			result := lib.JobResult{Kind: lib.ResourceKindPipeline, Id: o.Pipeline.Id, Status: lib.DeleteResultDeleted, Reason: o.Reason}
+			// the job may outlive the token of the request
+			token, err := cs.keycloak.GetAccessToken(ctx)
+			if err == nil {
+				err = failedDeletes(cs.deletePipelineResources(ctx, deletion, token))
+			}
+			if err != nil {
+				result.Status = lib.DeleteResultFailed
+				result.Error = err.Error()
+				return result
+			}
+			if cs.IsDryRun(ctx) {
+				result.Status = lib.DeleteResultWouldDelete
+				return result
+			}
+			util.Logger.Info("deleted ownerless pipeline", "pipeline", o.Pipeline.Id, "user", o.Pipeline.UserId, "reason", o.Reason)
+			return result
+		}})
+	}
+	return
+}
+
+// GetPipelinesWithDanglingFlows returns pipelines whose flow no longer exists in the flow repository.
+// Such pipelines can not be recreated.
+func (cs *CleanupService) GetPipelinesWithDanglingFlows(ctx context.Context, userId string, authToken string) (orphans []lib.Orphan[pipeModels.Pipeline], err error) {
+	pipes, err := cs.pipeline.GetPipelines(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	missing := map[string]bool{}
+	var dangling []pipeModels.Pipeline
+	for _, pipe := range pipes {
+		if pipe.FlowId == "" {
+			continue
+		}
+		isMissing, ok := missing[pipe.FlowId]
+		if !ok {
+			isMissing, err = cs.flowMissing(ctx, pipe.FlowId, pipe.UserId, authToken)
+			if err != nil {
+				return
+			}
+			missing[pipe.FlowId] = isMissing
+		}
+		if isMissing {
+			dangling = append(dangling, pipe)
+		}
+	}
+	orphans = trackOrphans(cs.tracker, OrphanKindDanglingFlows, userId, dangling, pipelineKey)
 	return
 }
 
-func (cs *CleanupService) DeleteOrphanedPipelineService(id string, accessToken string) error {
-	return cs.pipeline.DeletePipeline(id, accessToken)
+func (cs *CleanupService) flowMissing(ctx context.Context, flowId string, userId string, authToken string) (bool, error) {
+	_, err := cs.flowRepo.GetFlow(ctx, flowId, userId, authToken)
+	var notFound *lib.NotFoundError
+	if errors.As(err, &notFound) {
+		return true, nil
+	}
+	return false, err
 }
 
-func (cs *CleanupService) DeleteOrphanedPipelineServices(userId string, authToken string) (pipes []pipeModels.Pipeline, err error) {
-	pipes, err = cs.GetOrphanedPipelineServices(userId, authToken)
+func (cs *CleanupService) getOwnerlessPipelines(ctx context.Context, userId string, authToken string) (pipes []pipeModels.Pipeline, orphans []lib.Orphan[lib.OwnerlessPipeline], err error) {
+	pipes, err = cs.pipeline.GetPipelines(ctx, userId, authToken)
 	if err != nil {
-		for _, pipe := range pipes {
-			err = cs.pipeline.DeletePipeline(pipe.Id, cs.keycloak.GetAccessToken())
+		return
+	}
+	reasons := map[string]string{}
+	var ownerless []lib.OwnerlessPipeline
+	for _, pipe := range pipes {
+		reason, ok := reasons[pipe.UserId]
+		if !ok {
+			reason, err = cs.getOwnerlessReason(ctx, pipe.UserId)
 			if err != nil {
 				return
 			}
+			reasons[pipe.UserId] = reason
+		}
+		if reason != "" {
+			ownerless = append(ownerless, lib.OwnerlessPipeline{Pipeline: pipe, Reason: reason})
 		}
 	}
+	orphans = trackOrphans(cs.tracker, OrphanKindOwnerlessPipelines, userId, ownerless, ownerlessPipelineKey)
 	return
 }
 
-func (cs *CleanupService) GetOrphanedAnalyticsWorkloads(userId string, authToken string) (orphanedAnalyticsWorkloads []lib.Workload, err error) {
-	pipes, err := cs.pipeline.GetPipelines(userId, authToken)
+// getOwnerlessReason returns why userId can not own pipelines anymore, or an empty string if the user is active.
+// Errors other than a missing user fail the lookup, so a keycloak outage does not turn every pipeline ownerless.
+func (cs *CleanupService) getOwnerlessReason(ctx context.Context, userId string) (reason string, err error) {
+	if userId == "" {
+		return lib.OwnerlessReasonUserNotFound, nil
+	}
+	user, err := cs.keycloak.GetUserByID(ctx, userId)
+	var notFound *lib.NotFoundError
+	if errors.As(err, &notFound) {
+		return lib.OwnerlessReasonUserNotFound, nil
+	}
 	if err != nil {
 		return
 	}
-	workloads, err := cs.driver.GetWorkloads(lib.PIPELINE)
+	if user == nil {
+		return "", errors.New("could not get user data from keycloak")
+	}
+	if user.Enabled != nil && !*user.Enabled {
+		return lib.OwnerlessReasonUserDisabled, nil
+	}
+	return "", nil
+}
+
+func (cs *CleanupService) GetOrphanedAnalyticsWorkloads(ctx context.Context, userId string, authToken string) (orphans []lib.Orphan[lib.Workload], err error) {
+	pipes, err := cs.pipeline.GetPipelines(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	workloads, err := cs.driver.GetWorkloads(ctx, lib.PIPELINE)
 	if err != nil {
 		return
 	}
+	var orphanedAnalyticsWorkloads []lib.Workload
 	for _, workload := range workloads {
-		if !workloadInPipes(workload, pipes) {
+		if !cs.ownership.workloadInPipes(workload, pipes) {
 			orphanedAnalyticsWorkloads = append(orphanedAnalyticsWorkloads, workload)
 		}
 	}
+	orphans = trackOrphans(cs.tracker, OrphanKindAnalyticsWorkloads, userId, orphanedAnalyticsWorkloads, workloadKey)
 	return
 }
 
-func (cs *CleanupService) DeleteOrphanedAnalyticsWorkload(name string) error {
-	return cs.driver.DeleteWorkload(name, lib.PIPELINE)
+// GetAnalyticsWorkloadOwnership reports the owning pipeline of every analytics workload and the rule that matched it.
+func (cs *CleanupService) GetAnalyticsWorkloadOwnership(ctx context.Context, userId string, authToken string) (matches []lib.OwnershipMatch, err error) {
+	pipes, err := cs.pipeline.GetPipelines(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	workloads, err := cs.driver.GetWorkloads(ctx, lib.PIPELINE)
+	if err != nil {
+		return
+	}
+	for _, workload := range workloads {
+		match, _ := cs.ownership.Owner(workload, pipes)
+		matches = append(matches, match)
+	}
+	return
+}
+
+func (cs *CleanupService) DeleteOrphanedAnalyticsWorkload(ctx context.Context, name string) error {
+	return cs.deleteWorkload(ctx, name, lib.PIPELINE)
 }
 
-func (cs *CleanupService) DeleteOrphanedAnalyticsWorkloads(userId string, authToken string) (workloads []lib.Workload, err error) {
-	workloads, err = cs.GetOrphanedAnalyticsWorkloads(userId, authToken)
+func (cs *CleanupService) DeleteOrphanedAnalyticsWorkloads(ctx context.Context, userId string, authToken string) (job lib.Job, err error) {
+	orphans, err := cs.GetOrphanedAnalyticsWorkloads(ctx, userId, authToken)
 	if err != nil {
-		for _, workload := range workloads {
-			err = cs.driver.DeleteWorkload(workload.Name, lib.PIPELINE)
-			if err != nil {
-				return
-			}
+		return
+	}
+	items := deleteJobItems(lib.ResourceKindWorkload, orphans, workloadKey, cs.DeleteOrphanedAnalyticsWorkload)
+	return cs.startJob(ctx, OrphanKindAnalyticsWorkloads, userId, items)
+}
+
+func (cs *CleanupService) GetOrphanedServingWorkloads(ctx context.Context, userId string, authToken string) (orphans []lib.Orphan[lib.Workload], err error) {
+	instances, err := cs.serving.GetInstances(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	workloads, err := cs.driver.GetWorkloads(ctx, lib.SERVING)
+	if err != nil {
+		return
+	}
+	var orphanedServingWorkloads []lib.Workload
+	for _, workload := range workloads {
+		if !workloadInServingInstances(workload, instances) {
+			orphanedServingWorkloads = append(orphanedServingWorkloads, workload)
 		}
 	}
+	orphans = trackOrphans(cs.tracker, OrphanKindServingWorkloads, userId, orphanedServingWorkloads, workloadKey)
 	return
 }
 
-func (cs *CleanupService) GetOrphanedKafkaTopics() (orphanedKafkaTopics []string, err error) {
-	envs, err := cs.driver.GetWorkloadEnvs(lib.PIPELINE)
+func (cs *CleanupService) DeleteOrphanedServingWorkload(ctx context.Context, name string) error {
+	return cs.deleteWorkload(ctx, name, lib.SERVING)
+}
+
+func (cs *CleanupService) DeleteOrphanedServingWorkloads(ctx context.Context, userId string, authToken string) (job lib.Job, err error) {
+	orphans, err := cs.GetOrphanedServingWorkloads(ctx, userId, authToken)
 	if err != nil {
 		return
 	}
-	topics, err := cs.kafkaAdmin.GetTopics()
+	items := deleteJobItems(lib.ResourceKindWorkload, orphans, workloadKey, cs.DeleteOrphanedServingWorkload)
+	return cs.startJob(ctx, OrphanKindServingWorkloads, userId, items)
+}
+
+// GetOrphanedServingKubeServices returns serving services that are not backed by a workload
+// of a serving instance that still exists in the serving registry.
+func (cs *CleanupService) GetOrphanedServingKubeServices(ctx context.Context, userId string, authToken string) (orphans []lib.Orphan[lib.KubeService], err error) {
+	instances, err := cs.serving.GetInstances(ctx, userId, authToken)
 	if err != nil {
 		return
 	}
+	workloads, err := cs.driver.GetWorkloads(ctx, lib.SERVING)
+	if err != nil {
+		return
+	}
+	var liveWorkloads []lib.Workload
+	for _, workload := range workloads {
+		if workloadInServingInstances(workload, instances) {
+			liveWorkloads = append(liveWorkloads, workload)
+		}
+	}
+	services, err := cs.driver.GetServices(ctx, lib.SERVING)
+	if err != nil {
+		return
+	}
+	var orphanedServices []lib.KubeService
+	for _, service := range services {
+		if !serviceInWorkloads(service, liveWorkloads) {
+			orphanedServices = append(orphanedServices, service)
+		}
+	}
+	orphans = trackOrphans(cs.tracker, OrphanKindServingKubeServices, userId, orphanedServices, serviceKey)
+	return
+}
+
+func (cs *CleanupService) DeleteOrphanedServingKubeServices(ctx context.Context, userId string, authToken string) (job lib.Job, err error) {
+	orphans, err := cs.GetOrphanedServingKubeServices(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	items := deleteJobItems(lib.ResourceKindService, orphans, serviceKey, func(ctx context.Context, id string) error {
+		return cs.DeleteOrphanedKubeService(ctx, lib.SERVING, id)
+	})
+	return cs.startJob(ctx, OrphanKindServingKubeServices, userId, items)
+}
+
+func (cs *CleanupService) GetOrphanedKafkaTopics(ctx context.Context) (orphans []lib.Orphan[string], err error) {
+	envs, err := cs.driver.GetWorkloadEnvs(ctx, lib.PIPELINE)
+	if err != nil {
+		return
+	}
+	topics, err := cs.kafkaAdmin.GetTopics(ctx)
+	if err != nil {
+		return
+	}
+	var orphanedKafkaTopics []string
 	for _, topic := range topics {
 		if isInternalAnalyticsTopic(topic) && !pipelineExists(topic, envs) {
 			orphanedKafkaTopics = append(orphanedKafkaTopics, topic)
 		}
 	}
+	orphans = trackOrphans(cs.tracker, OrphanKindKafkaTopics, "", orphanedKafkaTopics, topicKey)
 	return
 }
 
-func (cs *CleanupService) DeleteOrphanedKafkaTopic(topic string) error {
-	return cs.kafkaAdmin.DeleteTopic(topic)
+func (cs *CleanupService) DeleteOrphanedKafkaTopic(ctx context.Context, topic string) error {
+	return cs.deleteTopic(ctx, topic)
+}
+
+func (cs *CleanupService) DeleteOrphanedKafkaTopics(ctx context.Context, userId string) (job lib.Job, err error) {
+	orphans, err := cs.GetOrphanedKafkaTopics(ctx)
+	if err != nil {
+		return
+	}
+	items := deleteJobItems(lib.ResourceKindTopic, orphans, topicKey, cs.DeleteOrphanedKafkaTopic)
+	return cs.startJob(ctx, OrphanKindKafkaTopics, userId, items)
+}
+
+func (cs *CleanupService) GetDeleteOrphanedKafkaTopicsStatus() (lib.Job, error) {
+	return cs.jobs.Latest(OrphanKindKafkaTopics)
+}
+
+func (cs *CleanupService) StopDeleteOrphanedKafkaTopics() (err error) {
+	return cs.jobs.StopType(OrphanKindKafkaTopics)
 }
 
-func (cs *CleanupService) DeleteOrphanedKafkaTopics() (err error) {
-	cs.deleteMu.Lock()
-	if cs.deleteRunning {
-		cs.deleteMu.Unlock()
-		return lib.NewConflictError(errors.New("delete task already running"))
+// GetOrphanedKafkaOutputTopics returns operator output topics that are neither produced nor consumed
+// by any pipeline in the registry and not exported by any serving instance.
+func (cs *CleanupService) GetOrphanedKafkaOutputTopics(ctx context.Context, userId string, authToken string) (orphans []lib.Orphan[string], err error) {
+	pipes, err := cs.pipeline.GetPipelines(ctx, userId, authToken)
+	if err != nil {
+		return
 	}
-	cs.deleteRunning = true
-	ctx, cancelDelete := context.WithCancel(cs.ctx)
-	cs.deleteCancel = cancelDelete
-	topics, err := cs.GetOrphanedKafkaTopics()
+	instances, err := cs.serving.GetInstances(ctx, userId, authToken)
 	if err != nil {
 		return
 	}
-	cs.deleteStatus = lib.DeleteStatus{
-		Total:     len(topics),
-		Remaining: len(topics),
-		Running:   true,
-		Errors:    nil,
+	topics, err := cs.kafkaAdmin.GetTopics(ctx)
+	if err != nil {
+		return
 	}
-	cs.deleteMu.Unlock()
-
-	go func(ctx context.Context) {
-		for index, topic := range topics {
-			var errs []error
-			select {
-			case <-ctx.Done():
-				cs.deleteMu.Lock()
-				cs.deleteStatus.Running = false
-				cs.deleteStatus.Errors = append(cs.deleteStatus.Errors, errors.New("aborted"))
-				cs.deleteRunning = false
-				util.Logger.Info("aborted delete kafka topics")
-				cs.deleteMu.Unlock()
-				return
-			default:
-				err = cs.kafkaAdmin.DeleteTopic(topic)
-				if err != nil {
-					errs = append(errs, err)
-				} else {
-					util.Logger.Info("deleted orphaned kafka topic", "topic", topic)
-				}
-				cs.deleteMu.Lock()
-				cs.deleteStatus.Remaining = len(topics) - (index + 1)
-				cs.deleteStatus.Errors = errs
-				cs.deleteMu.Unlock()
-				// give kafka time to breath
-				time.Sleep(1 * time.Second)
-			}
+	expected := getExpectedTopics(pipes, instances)
+	var orphanedTopics []string
+	for _, topic := range topics {
+		if isOperatorOutputTopic(topic, cs.config.OperatorTopicPrefix) && !expected[topic] {
+			orphanedTopics = append(orphanedTopics, topic)
 		}
-		cs.deleteMu.Lock()
-		cs.deleteStatus.Running = false
-		cs.deleteRunning = false
-		cs.deleteMu.Unlock()
-	}(ctx)
+	}
+	orphans = trackOrphans(cs.tracker, OrphanKindKafkaOutputTopics, userId, orphanedTopics, topicKey)
 	return
 }
 
-func (cs *CleanupService) GetDeleteOrphanedKafkaTopicsStatus() lib.DeleteStatus {
-	cs.deleteMu.Lock()
-	defer cs.deleteMu.Unlock()
-	return cs.deleteStatus
+func (cs *CleanupService) DeleteOrphanedKafkaOutputTopic(ctx context.Context, topic string) error {
+	return cs.deleteTopic(ctx, topic)
 }
 
-func (cs *CleanupService) StopDeleteOrphanedKafkaTopics() (err error) {
-	cs.deleteMu.Lock()
-	cancel := cs.deleteCancel
-	cs.deleteMu.Unlock()
-
-	if cancel != nil {
-		cancel()
-		util.Logger.Debug("stopped delete orphaned kafka topics")
-	} else {
-		err = lib.NewConflictError(errors.New("deleteOrphanedKafkaTopics not running"))
+func (cs *CleanupService) DeleteOrphanedKafkaOutputTopics(ctx context.Context, userId string, authToken string) (job lib.Job, err error) {
+	orphans, err := cs.GetOrphanedKafkaOutputTopics(ctx, userId, authToken)
+	if err != nil {
+		return
 	}
-	return
+	items := deleteJobItems(lib.ResourceKindTopic, orphans, topicKey, cs.DeleteOrphanedKafkaOutputTopic)
+	return cs.startJob(ctx, OrphanKindKafkaOutputTopics, userId, items)
 }
 
-func (cs *CleanupService) GetOrphanedKubeServices(collection string) (orphanedServices []lib.KubeService, err error) {
-	workloads, err := cs.driver.GetWorkloads(collection)
+func (cs *CleanupService) GetDeleteOrphanedKafkaOutputTopicsStatus() (lib.Job, error) {
+	return cs.jobs.Latest(OrphanKindKafkaOutputTopics)
+}
+
+func (cs *CleanupService) StopDeleteOrphanedKafkaOutputTopics() (err error) {
+	return cs.jobs.StopType(OrphanKindKafkaOutputTopics)
+}
+
+// GetOrphanedKafkaConsumerGroups returns the analytics consumer groups without a running pipeline and without active members.
+func (cs *CleanupService) GetOrphanedKafkaConsumerGroups(ctx context.Context) (orphans []lib.Orphan[lib.ConsumerGroup], err error) {
+	envs, err := cs.driver.GetWorkloadEnvs(ctx, lib.PIPELINE)
 	if err != nil {
 		return
 	}
-	services, err := cs.driver.GetServices(collection)
+	groups, err := cs.kafkaAdmin.ListConsumerGroups(ctx)
 	if err != nil {
 		return
 	}
-	for _, service := range services {
-		if !serviceInWorkloads(service, workloads) {
-			orphanedServices = append(orphanedServices, service)
+	var candidates []string
+	for _, group := range groups {
+		if isAnalyticsConsumerGroup(group) && !pipelineExists(group, envs) {
+			candidates = append(candidates, group)
 		}
 	}
+	descriptions, err := cs.kafkaAdmin.DescribeConsumerGroups(ctx, candidates)
+	if err != nil {
+		return
+	}
+	var orphanedGroups []lib.ConsumerGroup
+	for _, description := range descriptions {
+		if description.Members == 0 {
+			orphanedGroups = append(orphanedGroups, description)
+		}
+	}
+	orphans = trackOrphans(cs.tracker, OrphanKindKafkaConsumerGroups, "", orphanedGroups, groupKey)
 	return
 }
 
-func (cs *CleanupService) DeleteOrphanedKubeService(collection string, id string) error {
-	return cs.driver.DeleteService(id, collection)
+func (cs *CleanupService) DeleteOrphanedKafkaConsumerGroup(ctx context.Context, id string) error {
+	return cs.guardDelete(ctx, func() error {
+		return cs.kafkaAdmin.DeleteConsumerGroup(ctx, id)
+	})
 }
 
-func (cs *CleanupService) DeleteOrphanedKubeServices(collection string) (deletedKubeServices []lib.KubeService, err error) {
-	services, err := cs.GetOrphanedKubeServices(collection)
+func (cs *CleanupService) DeleteOrphanedKafkaConsumerGroups(ctx context.Context, userId string) (job lib.Job, err error) {
+	orphans, err := cs.GetOrphanedKafkaConsumerGroups(ctx)
 	if err != nil {
 		return
 	}
+	items := deleteJobItems(lib.ResourceKindConsumerGroup, orphans, groupKey, cs.DeleteOrphanedKafkaConsumerGroup)
+	return cs.startJob(ctx, OrphanKindKafkaConsumerGroups, userId, items)
+}
+
+func (cs *CleanupService) GetOrphanedKubeServices(ctx context.Context, collection string) (orphans []lib.Orphan[lib.KubeService], err error) {
+	workloads, err := cs.driver.GetWorkloads(ctx, collection)
+	if err != nil {
+		return
+	}
+	services, err := cs.driver.GetServices(ctx, collection)
+	if err != nil {
+		return
+	}
+	var orphanedServices []lib.KubeService
 	for _, service := range services {
-		err = cs.driver.DeleteService(service.Id, collection)
-		if err != nil {
-			return
+		if !serviceInWorkloads(service, workloads) {
+			orphanedServices = append(orphanedServices, service)
 		}
-		deletedKubeServices = append(deletedKubeServices, service)
 	}
+	orphans = trackOrphans(cs.tracker, OrphanKindKubeServices+":"+collection, "", orphanedServices, serviceKey)
 	return
 }
 
-func (cs *CleanupService) recreatePipelines(pipelines []pipeModels.Pipeline, workloads []lib.Workload) error {
-	cs.logger.Print("**************** Recreate Pipelines *********************")
-	for _, pipeline := range pipelines {
-		if !pipeInWorkloads(pipeline, workloads) {
-			cs._logPrint(pipeline.Id, pipeline.Name, pipeline.UserId)
-			pipe := &lib.Pipeline{Pipeline: pipeline}
-			request := pipe.ToRequest()
-			userToken, err := cs.keycloak.GetImpersonateToken(pipeline.UserId)
-			if err != nil {
-				return err
-			}
-			err = cs.pipeline.CreatePipeline(request, pipeline.UserId, userToken)
-			if err != nil {
-				cs.logger.Print(err.Error() + ", User: " + pipeline.UserId + ", Pipeline " + pipeline.Id)
-			}
-		}
-	}
-	return nil
+func (cs *CleanupService) DeleteOrphanedKubeService(ctx context.Context, collection string, id string) error {
+	return cs.deleteService(ctx, id, collection)
 }
 
-func (cs *CleanupService) _getKeycloakUserById(id string) (user *gocloak.User) {
-	user, err := cs.keycloak.GetUserByID(id)
+func (cs *CleanupService) DeleteOrphanedKubeServices(ctx context.Context, collection string, userId string) (job lib.Job, err error) {
+	orphans, err := cs.GetOrphanedKubeServices(ctx, collection)
 	if err != nil {
-		log.Fatal("GetUserByID failed:" + err.Error())
-	}
-	if user == nil {
-		log.Fatal("could not get user data from keycloak")
+		return
 	}
-	return
+	items := deleteJobItems(lib.ResourceKindService, orphans, serviceKey, func(ctx context.Context, id string) error {
+		return cs.DeleteOrphanedKubeService(ctx, collection, id)
+	})
+	return cs.startJob(ctx, OrphanKindKubeServices+":"+collection, userId, items)
 }
 
 func (cs *CleanupService) _logPrint(vars ...string) {
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/apis"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+func newFlowTestService(t *testing.T, flows map[string]any) *CleanupService {
+	pipes := newTestServer(t, map[string]any{
+		"GET /admin/pipeline": pipeModels.PipelinesResponse{Total: 4, Data: []pipeModels.Pipeline{
+			{Id: "p1", FlowId: "f1", UserId: "u1"},
+			{Id: "p2", FlowId: "f2", UserId: "u1"},
+			{Id: "p3", FlowId: "f2", UserId: "u2"},
+			{Id: "p4", UserId: "u2"},
+		}},
+	})
+	cs := newTestService(t, newFakeDriver())
+	cs.pipeline = *apis.NewPipelineService(pipes.URL, pipes.URL, 0)
+	cs.flowRepo = *apis.NewFlowRepoService(newTestServer(t, flows).URL, 0)
+	return cs
+}
+
+func TestGetPipelinesWithDanglingFlows(t *testing.T) {
+	cs := newFlowTestService(t, map[string]any{
+		"GET /flow/f1": lib.Flow{Id: "f1"},
+		"GET /flow/f2": http.StatusNotFound,
+	})
+	orphans, err := cs.GetPipelinesWithDanglingFlows(context.Background(), "admin", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pipelines without flow are not dangling
+	if len(orphans) != 2 || orphans[0].Resource.Id != "p2" || orphans[1].Resource.Id != "p3" {
+		t.Fatalf("unexpected orphans %+v", orphans)
+	}
+}
+
+func TestGetPipelinesWithDanglingFlowsRepoFailure(t *testing.T) {
+	cs := newFlowTestService(t, map[string]any{
+		"GET /flow/f1": lib.Flow{Id: "f1"},
+		"GET /flow/f2": http.StatusInternalServerError,
+	})
+	// an unreachable flow repository must not turn every pipeline dangling
+	if _, err := cs.GetPipelinesWithDanglingFlows(context.Background(), "admin", "token"); err == nil {
+		t.Fatal("expected error")
+	}
+}
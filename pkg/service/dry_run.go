@@ -0,0 +1,194 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+// ErrDryRun is returned in place of a delete that was skipped because of a dry run.
+var ErrDryRun = errors.New("dry run")
+
+type dryRunKey struct{}
+
+// WithDryRun marks ctx as dry run. Deletes requested with it run the full detection and verification, but leave
+// the resources untouched.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether deletes requested with ctx are skipped, either because of ctx or the DryRun config.
+func (cs *CleanupService) IsDryRun(ctx context.Context) bool {
+	return cs.config.DryRun || ctx.Value(dryRunKey{}) != nil
+}
+
+// guardDelete calls remove unless ctx is a dry run, ErrDryRun is returned then.
+func (cs *CleanupService) guardDelete(ctx context.Context, remove func() error) error {
+	if cs.IsDryRun(ctx) {
+		return ErrDryRun
+	}
+	return remove()
+}
+
+// startJob starts items as job. Dry runs process the items right away with ctx, they do not take long without the
+// deletes, and return the finished job without storing it.
+func (cs *CleanupService) startJob(ctx context.Context, jobType string, userId string, items []jobItem) (lib.Job, error) {
+	if !cs.IsDryRun(ctx) {
+		return cs.jobs.start(jobType, userId, items)
+	}
+	job := lib.Job{
+		Type:      jobType,
+		UserId:    userId,
+		Status:    lib.JobStatusCompleted,
+		Total:     len(items),
+		CreatedAt: time.Now().UTC(),
+		Results:   []lib.JobResult{},
+		DryRun:    true,
+	}
+	for index, item := range items {
+		if ctx.Err() != nil {
+			job.Status = lib.JobStatusCanceled
+			for _, pending := range items[index:] {
+				job.Pending = append(job.Pending, pending.JobItem)
+			}
+			break
+		}
+		result := item.run(ctx)
+		job.Results = append(job.Results, result)
+		job.Processed++
+		if result.Status == lib.DeleteResultFailed {
+			job.Failed++
+		}
+	}
+	if job.Status == lib.JobStatusCompleted && job.Failed > 0 {
+		job.Status = lib.JobStatusFailed
+	}
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	return job, nil
+}
+
+// orphanReason explains why an orphan is deleted.
+func orphanReason(state lib.OrphanState) string {
+	return "orphaned since " + state.FirstSeen.Format(time.RFC3339) + ", seen in " + strconv.Itoa(state.Scans) + " scans"
+}
+
+// DeleteOrphan deletes the single orphan id of kind. Like the bulk deletes, only orphans are deleted: the resource is
+// looked up first, a lib.NotFoundError is returned if it does not exist, and it must be among the detected orphans of
+// kind, otherwise a lib.ConflictError is returned. The grace period does not apply, the caller picked the orphan.
+// Dry runs stop right before the delete and return a lib.DeleteResultWouldDelete result.
+func (cs *CleanupService) DeleteOrphan(ctx context.Context, kind string, resourceKind string, id string, userId string, token string) (result lib.DeleteResult, err error) {
+	task, err := cs.getOrphanTask(ctx, kind, userId, token)
+	if err != nil {
+		return
+	}
+	if task.remove == nil {
+		return result, lib.NewInputError(errors.New("delete not supported for " + kind))
+	}
+	key, err := cs.lookupResource(ctx, kind, id, userId, token)
+	if err != nil {
+		return
+	}
+	entries, err := task.detect()
+	if err != nil {
+		return
+	}
+	index := slices.IndexFunc(entries, func(entry lib.OrphanEntry) bool {
+		return entry.Id == key
+	})
+	if index < 0 {
+		return result, lib.NewConflictError(errors.New(resourceKind + " " + id + " is not orphaned"))
+	}
+	err = task.remove(id)
+	if err != nil && !errors.Is(err, ErrDryRun) {
+		return
+	}
+	result = newDeleteResult(resourceKind, id, err)
+	result.Reason = orphanReason(entries[index].OrphanState)
+	return result, nil
+}
+
+// lookupResource returns the orphan key of the resource id of kind. Workloads are found by id or name, like the
+// drivers delete them.
+func (cs *CleanupService) lookupResource(ctx context.Context, kind string, id string, userId string, token string) (key string, err error) {
+	notFound := lib.NewNotFoundError(errors.New(kind + " " + id + " not found"))
+	switch kind {
+	case OrphanKindPipelines, OrphanKindOwnerlessPipelines:
+		pipes, err := cs.pipeline.GetPipelines(ctx, userId, token)
+		if err != nil {
+			return "", err
+		}
+		for _, pipe := range pipes {
+			if pipe.Id == id {
+				return pipe.Id, nil
+			}
+		}
+	case OrphanKindAnalyticsWorkloads, OrphanKindServingWorkloads:
+		collection := lib.PIPELINE
+		if kind == OrphanKindServingWorkloads {
+			collection = lib.SERVING
+		}
+		workloads, err := cs.driver.GetWorkloads(ctx, collection)
+		if err != nil {
+			return "", err
+		}
+		for _, workload := range workloads {
+			if workload.Id == id || workload.Name == id {
+				return workloadKey(workload), nil
+			}
+		}
+	case OrphanKindKubeServices, OrphanKindServingKubeServices:
+		collection := lib.PIPELINE
+		if kind == OrphanKindServingKubeServices {
+			collection = lib.SERVING
+		}
+		services, err := cs.driver.GetServices(ctx, collection)
+		if err != nil {
+			return "", err
+		}
+		for _, service := range services {
+			if service.Id == id {
+				return serviceKey(service), nil
+			}
+		}
+	case OrphanKindKafkaTopics, OrphanKindKafkaOutputTopics:
+		topics, err := cs.kafkaAdmin.GetTopics(ctx)
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(topics, id) {
+			return id, nil
+		}
+	case OrphanKindKafkaConsumerGroups:
+		groups, err := cs.kafkaAdmin.ListConsumerGroups(ctx)
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(groups, id) {
+			return id, nil
+		}
+	default:
+		return "", lib.NewInputError(errors.New("unknown kind " + kind))
+	}
+	return "", notFound
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func TestDeleteOrphan(t *testing.T) {
+	driver := newFakeDriver()
+	driver.workloads[lib.PIPELINE] = []lib.Workload{{Id: "deployment:pipes:op-1", Name: "op-1", Type: lib.WorkloadTypeDeployment}}
+	driver.services[lib.PIPELINE] = []lib.KubeService{
+		{Id: "pipes:s1", TargetWorkloadIds: []string{"deployment:pipes:op-1"}},
+		{Id: "pipes:s2", TargetWorkloadIds: []string{"deployment:pipes:op-2"}},
+	}
+	cs := newTestService(t, driver)
+
+	for _, ctx := range []context.Context{WithDryRun(context.Background()), context.Background()} {
+		_, err := cs.DeleteOrphan(ctx, OrphanKindKubeServices, lib.ResourceKindService, "pipes:s1", "admin", "token")
+		var conflict *lib.ConflictError
+		if !errors.As(err, &conflict) {
+			t.Errorf("expected conflict for service with live target, got %v", err)
+		}
+		_, err = cs.DeleteOrphan(ctx, OrphanKindKubeServices, lib.ResourceKindService, "pipes:missing", "admin", "token")
+		var notFound *lib.NotFoundError
+		if !errors.As(err, &notFound) {
+			t.Errorf("expected not found for missing service, got %v", err)
+		}
+	}
+
+	result, err := cs.DeleteOrphan(WithDryRun(context.Background()), OrphanKindKubeServices, lib.ResourceKindService, "pipes:s2", "admin", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != lib.DeleteResultWouldDelete || result.Reason == "" {
+		t.Errorf("expected would delete with reason, got %+v", result)
+	}
+	if len(driver.deleted) != 0 {
+		t.Fatalf("dry run deleted %v", driver.deleted)
+	}
+
+	result, err = cs.DeleteOrphan(context.Background(), OrphanKindKubeServices, lib.ResourceKindService, "pipes:s2", "admin", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != lib.DeleteResultDeleted || result.Reason == "" {
+		t.Errorf("expected deleted with reason, got %+v", result)
+	}
+	if !slices.Equal(driver.deleted, []string{"service:pipes:s2"}) {
+		t.Errorf("unexpected deletes %v", driver.deleted)
+	}
+}
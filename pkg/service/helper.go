@@ -24,29 +24,33 @@ import (
 	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
 )
 
-func pipeInWorkloads(pipe pipeModels.Pipeline, workloads []lib.Workload) bool {
-	for _, workload := range workloads {
-		if strings.Contains(workload.Name, pipe.Id) {
+func workloadInServingInstances(workload lib.Workload, instances []lib.ServingInstance) bool {
+	for _, instance := range instances {
+		if instance.Id == "" {
+			continue
+		}
+		if workload.Name == getServingInstanceName(instance.Id) || strings.Contains(workload.Name, instance.Id) {
 			return true
 		}
 	}
 	return false
 }
 
-func workloadInPipes(workload lib.Workload, pipes []pipeModels.Pipeline) bool {
-	for _, pipe := range pipes {
-		if strings.Contains(workload.Name, pipe.Id) {
-			return true
-		}
-	}
-	return false
+func getServingInstanceName(id string) string {
+	return "kafka2influx-" + id
 }
 
 func serviceInWorkloads(service lib.KubeService, workloads []lib.Workload) bool {
-	ws := strings.Split(service.TargetWorkloadIds[0], ":")
-	if len(ws) == 3 {
+	for _, targetId := range service.TargetWorkloadIds {
+		workloadType, _, name, ok := lib.ParseWorkloadId(targetId)
+		if !ok {
+			continue
+		}
 		for _, workload := range workloads {
-			if workload.Name == ws[2] {
+			if workload.Cluster != service.Cluster {
+				continue
+			}
+			if workload.Id == targetId || (workload.Name == name && workload.Type == workloadType) {
 				return true
 			}
 		}
@@ -61,6 +65,64 @@ func isInternalAnalyticsTopic(topic string) bool {
 	return kafkaInternalAnalyticsRx.MatchString(topic)
 }
 
+func isOperatorOutputTopic(topic string, prefix string) bool {
+	return prefix != "" && strings.HasPrefix(topic, prefix) && !isInternalAnalyticsTopic(topic)
+}
+
+// getExpectedTopics collects all topics produced or consumed by pipes and consumed by serving instances.
+func getExpectedTopics(pipes []pipeModels.Pipeline, instances []lib.ServingInstance) map[string]bool {
+	expected := map[string]bool{}
+	for _, pipe := range pipes {
+		for _, operator := range pipe.Operators {
+			if operator.OutputTopic != "" {
+				expected[operator.OutputTopic] = true
+			}
+			for _, inputTopic := range operator.InputTopics {
+				if inputTopic.Name != "" {
+					expected[inputTopic.Name] = true
+				}
+			}
+		}
+	}
+	for _, instance := range instances {
+		if instance.Topic != "" {
+			expected[instance.Topic] = true
+		}
+	}
+	return expected
+}
+
+// getTopicPipelineId returns the id of the pipeline an internal analytics topic belongs to.
+func getTopicPipelineId(topic string) string {
+	match := kafkaInternalAnalyticsPipelineIdRx.FindStringSubmatch(topic)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// getPipelineTopics returns the internal topics of pipe and its output topics that are not expected by anyone else.
+func getPipelineTopics(pipe pipeModels.Pipeline, topics []string, expected map[string]bool) (pipeTopics []string) {
+	existing := map[string]bool{}
+	for _, topic := range topics {
+		existing[topic] = true
+		if isInternalAnalyticsTopic(topic) && getTopicPipelineId(topic) == pipe.Id {
+			pipeTopics = append(pipeTopics, topic)
+		}
+	}
+	for _, operator := range pipe.Operators {
+		if operator.OutputTopic != "" && existing[operator.OutputTopic] && !expected[operator.OutputTopic] {
+			pipeTopics = append(pipeTopics, operator.OutputTopic)
+			existing[operator.OutputTopic] = false
+		}
+	}
+	return
+}
+
+func isAnalyticsConsumerGroup(group string) bool {
+	return kafkaInternalAnalyticsPipelineIdRx.MatchString(group)
+}
+
 func pipelineExists(topic string, envs []map[string]string) bool {
 	id := kafkaInternalAnalyticsPipelineIdRx.FindString(topic)
 	for _, env := range envs {
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/apis"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/config"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+)
+
+func init() {
+	util.Logger = slog.New(slog.DiscardHandler)
+}
+
+// fakeDriver keeps workloads and services per collection in memory.
+type fakeDriver struct {
+	mu        sync.Mutex
+	workloads map[string][]lib.Workload
+	services  map[string][]lib.KubeService
+	deleted   []string
+	// onDelete is called after every service delete, the driver is not locked then
+	onDelete func(id string)
+	// beforeWorkloadDelete is called before a workload delete, which fails if ctx is done by then
+	beforeWorkloadDelete func(id string)
+}
+
+func newFakeDriver() *fakeDriver {
+	return &fakeDriver{workloads: map[string][]lib.Workload{}, services: map[string][]lib.KubeService{}}
+}
+
+func (f *fakeDriver) GetServices(_ context.Context, collection string) ([]lib.KubeService, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.services[collection]), nil
+}
+
+func (f *fakeDriver) GetWorkloads(_ context.Context, collection string) ([]lib.Workload, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.workloads[collection]), nil
+}
+
+func (f *fakeDriver) GetWorkloadEnvs(_ context.Context, collection string) (envs []map[string]string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, workload := range f.workloads[collection] {
+		envs = append(envs, workload.Environment)
+	}
+	return
+}
+
+func (f *fakeDriver) DeleteWorkload(ctx context.Context, id string, collection string) error {
+	if f.beforeWorkloadDelete != nil {
+		f.beforeWorkloadDelete(id)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	index := slices.IndexFunc(f.workloads[collection], func(w lib.Workload) bool {
+		return w.Id == id || w.Name == id
+	})
+	if index < 0 {
+		return lib.NewNotFoundError(errors.New("workload " + id + " not found"))
+	}
+	f.deleted = append(f.deleted, lib.ResourceKindWorkload+":"+f.workloads[collection][index].Id)
+	f.workloads[collection] = slices.Delete(f.workloads[collection], index, index+1)
+	return nil
+}
+
+func (f *fakeDriver) DeleteService(_ context.Context, id string, collection string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	index := slices.IndexFunc(f.services[collection], func(s lib.KubeService) bool {
+		return s.Id == id
+	})
+	if index < 0 {
+		return lib.NewNotFoundError(errors.New("service " + id + " not found"))
+	}
+	f.deleted = append(f.deleted, lib.ResourceKindService+":"+id)
+	f.services[collection] = slices.Delete(f.services[collection], index, index+1)
+	if f.onDelete != nil {
+		f.mu.Unlock()
+		defer f.mu.Lock()
+		f.onDelete(id)
+	}
+	return nil
+}
+
+func (f *fakeDriver) ExportWorkload(_ context.Context, id string, collection string) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, workload := range f.workloads[collection] {
+		if workload.Id == id || workload.Name == id {
+			return json.Marshal(workload)
+		}
+	}
+	return nil, lib.NewNotFoundError(errors.New("workload " + id + " not found"))
+}
+
+func (f *fakeDriver) ExportService(_ context.Context, id string, collection string) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, service := range f.services[collection] {
+		if service.Id == id {
+			return json.Marshal(service)
+		}
+	}
+	return nil, lib.NewNotFoundError(errors.New("service " + id + " not found"))
+}
+
+func (f *fakeDriver) RestoreWorkload(_ context.Context, spec json.RawMessage, collection string) error {
+	var workload lib.Workload
+	if err := json.Unmarshal(spec, &workload); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.workloads[collection] = append(f.workloads[collection], workload)
+	return nil
+}
+
+func (f *fakeDriver) RestoreService(_ context.Context, spec json.RawMessage, collection string) error {
+	var service lib.KubeService
+	if err := json.Unmarshal(spec, &service); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.services[collection] = append(f.services[collection], service)
+	return nil
+}
+
+// newTestServer stands in for a backend, routes maps a pattern to the json response or to a status code.
+func newTestServer(t *testing.T, routes map[string]any) *httptest.Server {
+	mux := http.NewServeMux()
+	for pattern, v := range routes {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if status, ok := v.(int); ok {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(v)
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestKeycloak stands in for the token and userinfo endpoints the service account uses.
+func newTestKeycloak(t *testing.T) *apis.KeycloakService {
+	server := newTestServer(t, map[string]any{
+		"POST /auth/realms/test/protocol/openid-connect/token":   map[string]any{"access_token": "token", "expires_in": 300},
+		"GET /auth/realms/test/protocol/openid-connect/userinfo": map[string]any{"sub": "service-account"},
+	})
+	return apis.NewKeycloakService(server.URL, "client", "secret", "test", "user", "password", time.Second)
+}
+
+// newTestService creates a service on driver, orphans are deletable the first time they are seen.
+func newTestService(t *testing.T, driver Driver) *CleanupService {
+	tracker, err := NewOrphanTracker("", 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownership, err := NewOwnershipMatcher(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{DataDir: t.TempDir()}
+	return &CleanupService{
+		driver:    driver,
+		ownership: ownership,
+		tracker:   tracker,
+		config:    cfg,
+		ctx:       context.Background(),
+	}
+}
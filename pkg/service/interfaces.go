@@ -16,12 +16,24 @@
 
 package service
 
-import "github.com/SENERGY-Platform/analytics-cleanup/lib"
+import (
+	"context"
+	"encoding/json"
 
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+// Driver abstracts the platform the analytics workloads run on.
+// DeleteWorkload accepts either a plain workload name or a full workload id (<type>:<namespace>:<name>).
 type Driver interface {
-	GetServices(collection string) (services []lib.KubeService, err error)
-	GetWorkloads(collection string) (workloads []lib.Workload, err error)
-	GetWorkloadEnvs(collection string) (envs []map[string]string, err error)
-	DeleteWorkload(id string, collection string) error
-	DeleteService(id string, collection string) error
+	GetServices(ctx context.Context, collection string) (services []lib.KubeService, err error)
+	GetWorkloads(ctx context.Context, collection string) (workloads []lib.Workload, err error)
+	GetWorkloadEnvs(ctx context.Context, collection string) (envs []map[string]string, err error)
+	DeleteWorkload(ctx context.Context, id string, collection string) error
+	DeleteService(ctx context.Context, id string, collection string) error
+	// ExportWorkload and ExportService return a spec the matching restore creates the resource from again.
+	ExportWorkload(ctx context.Context, id string, collection string) (json.RawMessage, error)
+	ExportService(ctx context.Context, id string, collection string) (json.RawMessage, error)
+	RestoreWorkload(ctx context.Context, spec json.RawMessage, collection string) error
+	RestoreService(ctx context.Context, spec json.RawMessage, collection string) error
 }
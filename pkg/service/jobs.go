@@ -0,0 +1,578 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+	"github.com/google/uuid"
+)
+
+const JobTypeRecreatePipelines = "recreatepipelines"
+
+const topicDeletePause = 1 * time.Second
+
+// jobItem is one unit of work of a job.
+type jobItem struct {
+	lib.JobItem
+	run func(ctx context.Context) lib.JobResult
+}
+
+type job struct {
+	status lib.Job
+	cancel context.CancelFunc
+	// done is closed once the current run of the job returned
+	done chan struct{}
+}
+
+// JobManager runs bulk operations in the background and keeps the last finished ones for inspection.
+// Only one job per type can run at a time. Every job is checkpointed to its own file in dir after each item,
+// jobs that were running when the service stopped are loaded as interrupted.
+type JobManager struct {
+	ctx     context.Context
+	dir     string
+	history int
+	jobs    []*job
+	mu      sync.Mutex
+}
+
+// NewJobManager creates a manager whose jobs are bound to ctx and loads the jobs persisted in dir.
+// history is the number of finished jobs that are kept. An empty dir keeps the jobs in memory only.
+func NewJobManager(ctx context.Context, dir string, history int) (*JobManager, error) {
+	m := &JobManager{ctx: ctx, dir: dir, history: history}
+	if dir == "" {
+		return m, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		j := &job{cancel: func() {}}
+		if err = json.Unmarshal(data, &j.status); err != nil {
+			return nil, errors.New("could not parse " + path + ": " + err.Error())
+		}
+		if j.status.Status == lib.JobStatusRunning {
+			j.status.Status = lib.JobStatusInterrupted
+			util.Logger.Warn("found interrupted job", "job", j.status.Id, "type", j.status.Type, "pending", len(j.status.Pending))
+			if err = m.save(j); err != nil {
+				return nil, err
+			}
+		}
+		m.jobs = append(m.jobs, j)
+	}
+	slices.SortFunc(m.jobs, func(a, b *job) int {
+		return a.status.CreatedAt.Compare(b.status.CreatedAt)
+	})
+	m.prune()
+	return m, nil
+}
+
+// start runs items one after another in the background. The job is bound to the
+// context of the manager, not to the context of the triggering request.
+func (m *JobManager) start(jobType string, userId string, items []jobItem) (status lib.Job, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err = m.checkNotRunning(jobType); err != nil {
+		return
+	}
+	j := &job{
+		status: lib.Job{
+			Id:        uuid.NewString(),
+			Type:      jobType,
+			UserId:    userId,
+			CreatedAt: time.Now().UTC(),
+			Results:   []lib.JobResult{},
+		},
+	}
+	m.jobs = append(m.jobs, j)
+	m.prune()
+	m.launch(j, items)
+	return m.copyStatus(j), nil
+}
+
+// resume continues the interrupted or canceled job id with items, which replace its pending items.
+func (m *JobManager) resume(id string, items func(status lib.Job) ([]jobItem, error)) (status lib.Job, err error) {
+	m.mu.Lock()
+	j := m.find(id)
+	if j == nil {
+		m.mu.Unlock()
+		return status, lib.NewNotFoundError(errors.New("job " + id + " not found"))
+	}
+	if j.status.Status != lib.JobStatusInterrupted && j.status.Status != lib.JobStatusCanceled {
+		m.mu.Unlock()
+		return status, lib.NewConflictError(errors.New("job " + id + " is " + j.status.Status + ", only interrupted or canceled jobs can be resumed"))
+	}
+	status = m.copyStatus(j)
+	m.mu.Unlock()
+
+	// resolving the items may take a while, do not block the manager meanwhile
+	resumed, err := items(status)
+	if err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j.status.Status != status.Status {
+		return status, lib.NewConflictError(errors.New("job " + id + " changed while resuming"))
+	}
+	if err = m.checkNotRunning(j.status.Type); err != nil {
+		return
+	}
+	j.status.FinishedAt = nil
+	j.status.Total = j.status.Processed + len(resumed)
+	util.Logger.Info("resuming job", "job", id, "type", j.status.Type, "items", len(resumed))
+	m.launch(j, resumed)
+	return m.copyStatus(j), nil
+}
+
+// launch marks j as running and processes items in the background. Callers must hold m.mu.
+func (m *JobManager) launch(j *job, items []jobItem) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	j.cancel = cancel
+	j.done = make(chan struct{})
+	j.status.Status = lib.JobStatusRunning
+	j.status.Total = j.status.Processed + len(items)
+	j.status.Pending = make([]lib.JobItem, len(items))
+	for i, item := range items {
+		j.status.Pending[i] = item.JobItem
+	}
+	m.checkpoint(j)
+	util.Logger.Info("started job", "job", j.status.Id, "type", j.status.Type, "items", len(items))
+	go m.run(ctx, j, items)
+}
+
+func (m *JobManager) run(ctx context.Context, j *job, items []jobItem) {
+	defer close(j.done)
+	defer j.cancel()
+	for index, item := range items {
+		if pause := jobPause(j.status.Type, item.JobItem); index > 0 && pause > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pause):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		result := item.run(ctx)
+		m.mu.Lock()
+		j.status.Results = append(j.status.Results, result)
+		j.status.Pending = j.status.Pending[1:]
+		j.status.Processed++
+		if result.Status == lib.DeleteResultFailed {
+			j.status.Failed++
+		}
+		m.checkpoint(j)
+		m.mu.Unlock()
+	}
+	finished := time.Now().UTC()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx.Err() != nil && len(j.status.Pending) > 0 {
+		// the service is shutting down, leave the job running on disk so it is reported as interrupted
+		util.Logger.Info("interrupted job", "job", j.status.Id, "type", j.status.Type, "pending", len(j.status.Pending))
+		return
+	}
+	switch {
+	case len(j.status.Pending) > 0:
+		j.status.Status = lib.JobStatusCanceled
+	case j.status.Failed > 0:
+		j.status.Status = lib.JobStatusFailed
+	default:
+		j.status.Status = lib.JobStatusCompleted
+	}
+	j.status.FinishedAt = &finished
+	util.Logger.Info("finished job", "job", j.status.Id, "type", j.status.Type, "status", j.status.Status, "failed", j.status.Failed)
+	m.checkpoint(j)
+	m.prune()
+}
+
+// checkpoint persists j. A failed write is logged, the job goes on. Callers must hold m.mu.
+func (m *JobManager) checkpoint(j *job) {
+	if err := m.save(j); err != nil {
+		util.Logger.Error("could not persist job", "job", j.status.Id, "error", err)
+	}
+}
+
+func (m *JobManager) save(j *job) (err error) {
+	if m.dir == "" {
+		return
+	}
+	data, err := json.Marshal(j.status)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(m.dir, 0755); err != nil {
+		return
+	}
+	path := m.path(j.status.Id)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, path)
+}
+
+func (m *JobManager) path(id string) string {
+	return filepath.Join(m.dir, id+".json")
+}
+
+// prune drops the oldest finished jobs beyond the history limit. Interrupted jobs are kept until they are resumed.
+// Callers must hold m.mu.
+func (m *JobManager) prune() {
+	finished := 0
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		status := m.jobs[i].status
+		if status.Status == lib.JobStatusRunning || status.Status == lib.JobStatusInterrupted {
+			continue
+		}
+		finished++
+		if finished <= m.history {
+			continue
+		}
+		if m.dir != "" {
+			if err := os.Remove(m.path(status.Id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				util.Logger.Error("could not remove job", "job", status.Id, "error", err)
+			}
+		}
+		m.jobs = slices.Delete(m.jobs, i, i+1)
+	}
+}
+
+// checkNotRunning fails if a job of jobType is running. Callers must hold m.mu.
+func (m *JobManager) checkNotRunning(jobType string) error {
+	for _, j := range m.jobs {
+		if j.status.Type == jobType && j.status.Status == lib.JobStatusRunning {
+			return lib.NewConflictError(errors.New(jobType + " job " + j.status.Id + " already running"))
+		}
+	}
+	return nil
+}
+
+// copyStatus returns the status of j without sharing its slices. Callers must hold m.mu.
+func (m *JobManager) copyStatus(j *job) lib.Job {
+	status := j.status
+	status.Pending = slices.Clone(j.status.Pending)
+	status.Results = slices.Clone(j.status.Results)
+	return status
+}
+
+// List returns the jobs without their items and results, newest first. An empty jobType matches all jobs.
+func (m *JobManager) List(jobType string) (jobs []lib.Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs = []lib.Job{}
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		if jobType != "" && m.jobs[i].status.Type != jobType {
+			continue
+		}
+		status := m.jobs[i].status
+		status.Pending = nil
+		status.Results = nil
+		jobs = append(jobs, status)
+	}
+	return
+}
+
+// Interrupted returns the ids of all interrupted jobs, oldest first.
+func (m *JobManager) Interrupted() (ids []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.jobs {
+		if j.status.Status == lib.JobStatusInterrupted {
+			ids = append(ids, j.status.Id)
+		}
+	}
+	return
+}
+
+// wait blocks until the job id is no longer running or ctx is done and returns its status.
+func (m *JobManager) wait(ctx context.Context, id string) (status lib.Job, err error) {
+	m.mu.Lock()
+	j := m.find(id)
+	if j == nil {
+		m.mu.Unlock()
+		return status, lib.NewNotFoundError(errors.New("job " + id + " not found"))
+	}
+	done := j.done
+	m.mu.Unlock()
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return status, ctx.Err()
+		}
+	}
+	return m.Get(id)
+}
+
+func (m *JobManager) Get(id string) (status lib.Job, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j := m.find(id)
+	if j == nil {
+		return status, lib.NewNotFoundError(errors.New("job " + id + " not found"))
+	}
+	return m.copyStatus(j), nil
+}
+
+func (m *JobManager) Stop(id string) (err error) {
+	m.mu.Lock()
+	j := m.find(id)
+	m.mu.Unlock()
+	if j == nil {
+		return lib.NewNotFoundError(errors.New("job " + id + " not found"))
+	}
+	return m.stop(j)
+}
+
+// Latest returns the newest job of jobType.
+func (m *JobManager) Latest(jobType string) (status lib.Job, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		if m.jobs[i].status.Type == jobType {
+			return m.copyStatus(m.jobs[i]), nil
+		}
+	}
+	return status, lib.NewNotFoundError(errors.New("no " + jobType + " job found"))
+}
+
+// StopType stops the running job of jobType.
+func (m *JobManager) StopType(jobType string) (err error) {
+	m.mu.Lock()
+	var running *job
+	for _, j := range m.jobs {
+		if j.status.Type == jobType && j.status.Status == lib.JobStatusRunning {
+			running = j
+		}
+	}
+	m.mu.Unlock()
+	if running == nil {
+		return lib.NewConflictError(errors.New(jobType + " job not running"))
+	}
+	return m.stop(running)
+}
+
+func (m *JobManager) stop(j *job) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j.status.Status != lib.JobStatusRunning {
+		return lib.NewConflictError(errors.New("job " + j.status.Id + " not running"))
+	}
+	j.cancel()
+	util.Logger.Debug("stopped job", "job", j.status.Id, "type", j.status.Type)
+	return
+}
+
+// find returns the job with id or nil. Callers must hold m.mu.
+func (m *JobManager) find(id string) *job {
+	for _, j := range m.jobs {
+		if j.status.Id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// deleteJobItem deletes one resource with remove. Resources that are already gone count as deleted.
+// reason explains why the resource is deleted.
+func deleteJobItem(kind string, id string, reason string, remove func(ctx context.Context, id string) error) jobItem {
+	return jobItem{JobItem: lib.JobItem{Kind: kind, Id: id}, run: func(ctx context.Context) lib.JobResult {
+		result := newDeleteResult(kind, id, remove(ctx, id))
+		result.Reason = reason
+		if result.Status == lib.DeleteResultDeleted {
+			util.Logger.Info("deleted orphaned "+kind, "id", id)
+		}
+		return lib.JobResult(result)
+	}}
+}
+
+// skippedJobItem reports item as skipped without touching it.
+func skippedJobItem(item lib.JobItem, reason string) jobItem {
+	return jobItem{JobItem: item, run: func(context.Context) lib.JobResult {
+		return lib.JobResult{Kind: item.Kind, Id: item.Id, Status: lib.JobResultSkipped, Error: reason}
+	}}
+}
+
+// deleteJobItems creates one deleteJobItem per deletable orphan.
+func deleteJobItems[T any](kind string, orphans []lib.Orphan[T], key func(T) string, remove func(ctx context.Context, id string) error) (items []jobItem) {
+	for _, orphan := range orphans {
+		if orphan.Deletable {
+			items = append(items, deleteJobItem(kind, key(orphan.Resource), orphanReason(orphan.OrphanState), remove))
+		}
+	}
+	return
+}
+
+func (cs *CleanupService) GetJobs(jobType string) []lib.Job {
+	return cs.jobs.List(jobType)
+}
+
+func (cs *CleanupService) GetJob(id string) (lib.Job, error) {
+	return cs.jobs.Get(id)
+}
+
+func (cs *CleanupService) StopJob(id string) error {
+	return cs.jobs.Stop(id)
+}
+
+// ResumeJob continues the interrupted or canceled job id with the service account. Pending items are verified again
+// before they are processed, items that are no longer orphaned or without workloads are skipped.
+func (cs *CleanupService) ResumeJob(ctx context.Context, id string) (lib.Job, error) {
+	return cs.jobs.resume(id, func(status lib.Job) ([]jobItem, error) {
+		return cs.resumeItems(ctx, status)
+	})
+}
+
+// ResumeInterruptedJobs resumes all jobs that were interrupted by a restart. Failures are logged.
+func (cs *CleanupService) ResumeInterruptedJobs(ctx context.Context) {
+	for _, id := range cs.jobs.Interrupted() {
+		if _, err := cs.ResumeJob(ctx, id); err != nil {
+			util.Logger.Error("could not resume job", "job", id, "error", err)
+		}
+	}
+}
+
+// resumeItems verifies the pending items of status again. The orphans are detected in the scope of the user that
+// started the job, or created the plan, since their scans made the items deletable. The deletes use the ctx of the
+// resumed job, so stopping it aborts them.
+func (cs *CleanupService) resumeItems(ctx context.Context, status lib.Job) (items []jobItem, err error) {
+	serviceUserId, token, err := cs.GetServiceCredentials(ctx)
+	if err != nil {
+		return
+	}
+	if status.Type == JobTypeRecreatePipelines {
+		return cs.resumeRecreateItems(ctx, status.Pending, serviceUserId, token)
+	}
+	if status.Type == JobTypeApplyPlan {
+		plan, err := cs.planOfJob(status.Id)
+		if err != nil {
+			return nil, err
+		}
+		return cs.planItems(plan.UserId, status.Pending), nil
+	}
+	if status.Type == JobTypeOffboarding {
+		return cs.resumeOffboardingItems(ctx, status.Pending, serviceUserId, token)
+	}
+	detection, err := cs.getJobOrphanTask(ctx, status.Type, status.UserId, token)
+	if err != nil {
+		return
+	}
+	entries, err := detection.detect()
+	if err != nil {
+		return
+	}
+	orphans := map[string]lib.OrphanEntry{}
+	for _, entry := range entries {
+		orphans[entry.Id] = entry
+	}
+	for _, item := range status.Pending {
+		orphan, ok := orphans[item.Id]
+		if !ok || !orphan.Deletable {
+			items = append(items, skippedJobItem(item, "no longer a deletable orphan"))
+			continue
+		}
+		items = append(items, deleteJobItem(item.Kind, item.Id, orphanReason(orphan.OrphanState), func(ctx context.Context, id string) error {
+			deletion, err := cs.getJobOrphanTask(ctx, status.Type, status.UserId, token)
+			if err != nil {
+				return err
+			}
+			return deletion.remove(id)
+		}))
+	}
+	return
+}
+
+// getJobOrphanTask returns the orphan task of the delete jobs of jobType, every type the orphan deletes start a job
+// with is mapped. Unknown types are an error, their items would otherwise be resumed with the wrong detection.
+func (cs *CleanupService) getJobOrphanTask(ctx context.Context, jobType string, userId string, token string) (orphanTask, error) {
+	switch jobType {
+	case OrphanKindKubeServices + ":" + lib.PIPELINE, OrphanKindKubeServices + ":" + lib.SERVING:
+		collection := strings.TrimPrefix(jobType, OrphanKindKubeServices+":")
+		return newOrphanTask(OrphanKindKubeServices, func() ([]lib.Orphan[lib.KubeService], error) {
+			return cs.GetOrphanedKubeServices(ctx, collection)
+		}, serviceKey, func(id string) error {
+			return cs.DeleteOrphanedKubeService(ctx, collection, id)
+		}), nil
+	case OrphanKindPipelines, OrphanKindOwnerlessPipelines, OrphanKindAnalyticsWorkloads, OrphanKindServingWorkloads,
+		OrphanKindServingKubeServices, OrphanKindKafkaTopics, OrphanKindKafkaOutputTopics, OrphanKindKafkaConsumerGroups:
+		return cs.getOrphanTask(ctx, jobType, userId, token)
+	}
+	return orphanTask{}, lib.NewInputError(errors.New("jobs of unknown type " + jobType + " can not be resumed"))
+}
+
+func (cs *CleanupService) resumeRecreateItems(ctx context.Context, pending []lib.JobItem, userId string, token string) (items []jobItem, err error) {
+	request := lib.RecreateRequest{}
+	for _, item := range pending {
+		request.PipelineIds = append(request.PipelineIds, item.Id)
+	}
+	candidates := map[string]pipeModels.Pipeline{}
+	if len(request.PipelineIds) > 0 {
+		pipes, err := cs.getRecreateCandidates(ctx, request, userId, token)
+		if err != nil {
+			return nil, err
+		}
+		for _, pipe := range pipes {
+			candidates[pipe.Id] = pipe
+		}
+	}
+	for _, item := range pending {
+		pipe, ok := candidates[item.Id]
+		if !ok {
+			items = append(items, skippedJobItem(item, "no longer a recreate candidate"))
+			continue
+		}
+		items = append(items, cs.recreateJobItem(pipe))
+	}
+	return
+}
+
+// jobPause returns the pause before item of a job. Kafka gets time to breathe between two topic deletes.
+// Plan items carry their orphan kind, so the pause is decided per item there.
+func jobPause(jobType string, item lib.JobItem) time.Duration {
+	if jobType == JobTypeApplyPlan {
+		jobType = item.Kind
+	}
+	if jobType == OrphanKindKafkaTopics || jobType == OrphanKindKafkaOutputTopics {
+		return topicDeletePause
+	}
+	return 0
+}
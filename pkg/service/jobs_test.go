@@ -0,0 +1,133 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func TestGetJobOrphanTaskMapsEveryJobType(t *testing.T) {
+	cs := newTestService(t, newFakeDriver())
+	jobTypes := []string{
+		OrphanKindPipelines,
+		OrphanKindOwnerlessPipelines,
+		OrphanKindAnalyticsWorkloads,
+		OrphanKindServingWorkloads,
+		OrphanKindServingKubeServices,
+		OrphanKindKafkaTopics,
+		OrphanKindKafkaOutputTopics,
+		OrphanKindKafkaConsumerGroups,
+		OrphanKindKubeServices + ":" + lib.PIPELINE,
+		OrphanKindKubeServices + ":" + lib.SERVING,
+	}
+	for _, jobType := range jobTypes {
+		task, err := cs.getJobOrphanTask(context.Background(), jobType, "admin", "token")
+		if err != nil {
+			t.Errorf("%s: %v", jobType, err)
+		} else if task.remove == nil {
+			t.Errorf("%s: no delete", jobType)
+		}
+	}
+	for _, jobType := range []string{OrphanKindDanglingFlows, OrphanKindKubeServices, "unknown"} {
+		if _, err := cs.getJobOrphanTask(context.Background(), jobType, "admin", "token"); err == nil {
+			t.Errorf("%s: expected an error", jobType)
+		}
+	}
+}
+
+func TestServingKubeServiceJobsResumeOnServingCollection(t *testing.T) {
+	driver := newFakeDriver()
+	driver.services[lib.PIPELINE] = []lib.KubeService{{Id: "pipes:s1", TargetWorkloadIds: []string{"deployment:pipes:op-1"}}}
+	driver.services[lib.SERVING] = []lib.KubeService{{Id: "serving:s1", TargetWorkloadIds: []string{"deployment:serving:op-1"}}}
+	cs := newTestService(t, driver)
+	task, err := cs.getJobOrphanTask(context.Background(), OrphanKindKubeServices+":"+lib.SERVING, "admin", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := task.detect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Id != "serving:s1" {
+		t.Fatalf("expected the serving service, got %+v", entries)
+	}
+	if err = task.remove("serving:s1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(driver.deleted) != 1 || driver.deleted[0] != "service:serving:s1" {
+		t.Fatalf("expected the serving service to be deleted, got %v", driver.deleted)
+	}
+}
+
+// canceledJob starts a job of jobType for userId that is stopped while its first item runs, so pending stays pending.
+func canceledJob(t *testing.T, cs *CleanupService, jobType string, userId string, pending lib.JobItem) lib.Job {
+	started := make(chan struct{})
+	blocking := jobItem{JobItem: lib.JobItem{Kind: pending.Kind, Id: "blocking"}, run: func(ctx context.Context) lib.JobResult {
+		close(started)
+		<-ctx.Done()
+		return lib.JobResult{Kind: pending.Kind, Id: "blocking", Status: lib.JobResultSkipped}
+	}}
+	job, err := cs.startJob(context.Background(), jobType, userId, []jobItem{blocking, {JobItem: pending}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err = cs.StopJob(job.Id); err != nil {
+		t.Fatal(err)
+	}
+	job = waitForJob(t, cs, job.Id)
+	if job.Status != lib.JobStatusCanceled || len(job.Pending) != 1 {
+		t.Fatalf("expected a canceled job with one pending item, got %+v", job)
+	}
+	return job
+}
+
+func TestResumeJobInJobScope(t *testing.T) {
+	cs, driver := newServingTestService(t)
+	enableJobs(t, cs)
+	withGracePeriod(t, cs, 20*time.Millisecond)
+	// the scans of the admin made the workload deletable, the service account never saw it
+	if _, err := cs.GetOrphanedServingWorkloads(context.Background(), "admin", "token"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	pending := lib.JobItem{Kind: lib.ResourceKindWorkload, Id: "deployment:serving:kafka2influx-i2"}
+	job := canceledJob(t, cs, OrphanKindServingWorkloads, "admin", pending)
+
+	// stopping the resumed job aborts the running delete
+	driver.beforeWorkloadDelete = func(string) {
+		if err := cs.StopJob(job.Id); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := cs.ResumeJob(context.Background(), job.Id); err != nil {
+		t.Fatal(err)
+	}
+	job = waitForJob(t, cs, job.Id)
+	// verified in the scope of the admin, the item is attempted instead of skipped
+	last := job.Results[len(job.Results)-1]
+	if last.Id != pending.Id || last.Status == lib.JobResultSkipped {
+		t.Fatalf("expected the pending workload to be attempted, got %+v", last)
+	}
+	if len(driver.deleted) != 0 {
+		t.Fatalf("expected the stopped job to delete nothing, got %v", driver.deleted)
+	}
+}
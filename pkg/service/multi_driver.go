@@ -0,0 +1,237 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+// ClusterIdSeparator separates the cluster name from the driver specific id in ids returned by MultiClusterDriver.
+const ClusterIdSeparator = "/"
+
+type Cluster struct {
+	Name   string
+	Driver Driver
+}
+
+// MultiClusterDriver fans out to several drivers and tags every workload and service with its cluster.
+// Listings fail if a single cluster fails, so resources of an unreachable cluster are never reported as missing.
+type MultiClusterDriver struct {
+	clusters []Cluster
+}
+
+// NewMultiClusterDriver fails without clusters and for names that are empty, duplicated or contain ClusterIdSeparator.
+// Without clusters every listing is empty and everything in the registries would look orphaned, ambiguous names would
+// route deletes to the wrong cluster.
+func NewMultiClusterDriver(clusters []Cluster) (*MultiClusterDriver, error) {
+	if len(clusters) == 0 {
+		return nil, errors.New("multi cluster driver requires at least one cluster")
+	}
+	names := map[string]bool{}
+	for _, cluster := range clusters {
+		if cluster.Name == "" {
+			return nil, errors.New("cluster name must not be empty")
+		}
+		if strings.Contains(cluster.Name, ClusterIdSeparator) {
+			return nil, errors.New("cluster name " + cluster.Name + " must not contain " + ClusterIdSeparator)
+		}
+		if names[cluster.Name] {
+			return nil, errors.New("duplicate cluster name " + cluster.Name)
+		}
+		if cluster.Driver == nil {
+			return nil, errors.New("cluster " + cluster.Name + " has no driver")
+		}
+		names[cluster.Name] = true
+	}
+	return &MultiClusterDriver{clusters: clusters}, nil
+}
+
+func (m *MultiClusterDriver) GetServices(ctx context.Context, collection string) (services []lib.KubeService, err error) {
+	return fanOut(ctx, m.clusters, func(ctx context.Context, cluster Cluster) (services []lib.KubeService, err error) {
+		services, err = cluster.Driver.GetServices(ctx, collection)
+		for i := range services {
+			services[i].Id = cluster.Name + ClusterIdSeparator + services[i].Id
+			services[i].Cluster = cluster.Name
+		}
+		return
+	})
+}
+
+func (m *MultiClusterDriver) GetWorkloads(ctx context.Context, collection string) (workloads []lib.Workload, err error) {
+	return fanOut(ctx, m.clusters, func(ctx context.Context, cluster Cluster) (workloads []lib.Workload, err error) {
+		workloads, err = cluster.Driver.GetWorkloads(ctx, collection)
+		for i := range workloads {
+			workloads[i].Id = cluster.Name + ClusterIdSeparator + workloads[i].Id
+			workloads[i].Cluster = cluster.Name
+		}
+		return
+	})
+}
+
+func (m *MultiClusterDriver) GetWorkloadEnvs(ctx context.Context, collection string) (envs []map[string]string, err error) {
+	return fanOut(ctx, m.clusters, func(ctx context.Context, cluster Cluster) ([]map[string]string, error) {
+		return cluster.Driver.GetWorkloadEnvs(ctx, collection)
+	})
+}
+
+// DeleteWorkload routes the delete to the cluster encoded in id. Plain names are looked up in all clusters
+// and only deleted if exactly one cluster runs a workload with that name.
+func (m *MultiClusterDriver) DeleteWorkload(ctx context.Context, id string, collection string) (err error) {
+	cluster, id, err := m.resolveWorkload(ctx, id, collection)
+	if err != nil {
+		return
+	}
+	return cluster.Driver.DeleteWorkload(ctx, id, collection)
+}
+
+func (m *MultiClusterDriver) DeleteService(ctx context.Context, id string, collection string) (err error) {
+	cluster, id, ok := m.splitId(id)
+	if !ok {
+		return lib.NewInputError(errors.New("service id " + id + " does not reference a cluster"))
+	}
+	return cluster.Driver.DeleteService(ctx, id, collection)
+}
+
+// ExportWorkload wraps the spec of the cluster driver with the cluster name, so RestoreWorkload knows where it belongs.
+func (m *MultiClusterDriver) ExportWorkload(ctx context.Context, id string, collection string) (spec json.RawMessage, err error) {
+	cluster, id, err := m.resolveWorkload(ctx, id, collection)
+	if err != nil {
+		return
+	}
+	spec, err = cluster.Driver.ExportWorkload(ctx, id, collection)
+	if err != nil {
+		return
+	}
+	return json.Marshal(clusterSpec{Cluster: cluster.Name, Spec: spec})
+}
+
+func (m *MultiClusterDriver) RestoreWorkload(ctx context.Context, spec json.RawMessage, collection string) (err error) {
+	cluster, spec, err := m.unwrapSpec(spec)
+	if err != nil {
+		return
+	}
+	return cluster.Driver.RestoreWorkload(ctx, spec, collection)
+}
+
+func (m *MultiClusterDriver) ExportService(ctx context.Context, id string, collection string) (spec json.RawMessage, err error) {
+	cluster, id, ok := m.splitId(id)
+	if !ok {
+		return nil, lib.NewInputError(errors.New("service id " + id + " does not reference a cluster"))
+	}
+	spec, err = cluster.Driver.ExportService(ctx, id, collection)
+	if err != nil {
+		return
+	}
+	return json.Marshal(clusterSpec{Cluster: cluster.Name, Spec: spec})
+}
+
+func (m *MultiClusterDriver) RestoreService(ctx context.Context, spec json.RawMessage, collection string) (err error) {
+	cluster, spec, err := m.unwrapSpec(spec)
+	if err != nil {
+		return
+	}
+	return cluster.Driver.RestoreService(ctx, spec, collection)
+}
+
+type clusterSpec struct {
+	Cluster string          `json:"cluster"`
+	Spec    json.RawMessage `json:"spec"`
+}
+
+func (m *MultiClusterDriver) unwrapSpec(spec json.RawMessage) (cluster Cluster, driverSpec json.RawMessage, err error) {
+	var wrapped clusterSpec
+	if err = json.Unmarshal(spec, &wrapped); err != nil {
+		return
+	}
+	for _, c := range m.clusters {
+		if c.Name == wrapped.Cluster {
+			return c, wrapped.Spec, nil
+		}
+	}
+	return cluster, nil, lib.NewInputError(errors.New("unknown cluster " + wrapped.Cluster))
+}
+
+// resolveWorkload returns the cluster of id and the id within it. Plain names are looked up in all clusters
+// and only resolved if exactly one cluster runs a workload with that name.
+func (m *MultiClusterDriver) resolveWorkload(ctx context.Context, id string, collection string) (cluster Cluster, driverId string, err error) {
+	cluster, driverId, ok := m.splitId(id)
+	if ok {
+		return
+	}
+	workloads, err := m.GetWorkloads(ctx, collection)
+	if err != nil {
+		return
+	}
+	var matches []string
+	for _, workload := range workloads {
+		if workload.Name == id {
+			matches = append(matches, workload.Id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		err = lib.NewNotFoundError(errors.New("workload " + id + " not found"))
+	case 1:
+		cluster, driverId, _ = m.splitId(matches[0])
+	default:
+		err = lib.NewConflictError(errors.New("workload " + id + " exists in several clusters: " + strings.Join(matches, ", ")))
+	}
+	return
+}
+
+func (m *MultiClusterDriver) splitId(id string) (cluster Cluster, driverId string, ok bool) {
+	name, driverId, found := strings.Cut(id, ClusterIdSeparator)
+	if !found {
+		return cluster, id, false
+	}
+	for _, c := range m.clusters {
+		if c.Name == name {
+			return c, driverId, true
+		}
+	}
+	return cluster, id, false
+}
+
+func fanOut[T any](ctx context.Context, clusters []Cluster, f func(ctx context.Context, cluster Cluster) ([]T, error)) (result []T, err error) {
+	results := make([][]T, len(clusters))
+	errs := make([]error, len(clusters))
+	wg := sync.WaitGroup{}
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = f(ctx, cluster)
+			if errs[i] != nil {
+				errs[i] = errors.New("cluster " + cluster.Name + ": " + errs[i].Error())
+			}
+		}()
+	}
+	wg.Wait()
+	if err = errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		result = append(result, r...)
+	}
+	return
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func TestNewMultiClusterDriverValidation(t *testing.T) {
+	driver := newFakeDriver()
+	invalid := map[string][]Cluster{
+		"no clusters":    nil,
+		"empty name":     {{Name: "", Driver: driver}},
+		"separator":      {{Name: "a/b", Driver: driver}},
+		"duplicate name": {{Name: "a", Driver: driver}, {Name: "a", Driver: newFakeDriver()}},
+		"missing driver": {{Name: "a"}},
+	}
+	for name, clusters := range invalid {
+		if _, err := NewMultiClusterDriver(clusters); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := NewMultiClusterDriver([]Cluster{{Name: "a", Driver: driver}, {Name: "b", Driver: newFakeDriver()}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultiClusterDriverRouting(t *testing.T) {
+	a, b := newFakeDriver(), newFakeDriver()
+	a.workloads[lib.PIPELINE] = []lib.Workload{{Id: "deployment:pipes:op-1", Name: "op-1"}}
+	b.workloads[lib.PIPELINE] = []lib.Workload{{Id: "deployment:pipes:op-1", Name: "op-1"}, {Id: "deployment:pipes:op-2", Name: "op-2"}}
+	m, err := NewMultiClusterDriver([]Cluster{{Name: "a", Driver: a}, {Name: "b", Driver: b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	workloads, err := m.GetWorkloads(ctx, lib.PIPELINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workloads) != 3 || workloads[0].Id != "a/deployment:pipes:op-1" || workloads[0].Cluster != "a" {
+		t.Fatalf("unexpected workloads %+v", workloads)
+	}
+	// op-1 runs in both clusters, the plain name is ambiguous
+	var conflict *lib.ConflictError
+	if err = m.DeleteWorkload(ctx, "op-1", lib.PIPELINE); err == nil || !errors.As(err, &conflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if err = m.DeleteWorkload(ctx, "op-2", lib.PIPELINE); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.DeleteWorkload(ctx, "a/deployment:pipes:op-1", lib.PIPELINE); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(a.deleted, []string{"workload:deployment:pipes:op-1"}) || !slices.Equal(b.deleted, []string{"workload:deployment:pipes:op-2"}) {
+		t.Fatalf("unexpected deletes %v %v", a.deleted, b.deleted)
+	}
+}
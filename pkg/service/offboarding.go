@@ -0,0 +1,230 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+const JobTypeOffboarding = "offboarding"
+
+// DeleteUserResources starts a job deleting all pipelines of targetUserId with their workloads, services, topics and
+// consumer groups, one item per pipeline. Failures are recorded and do not stop the remaining deletes. The last item
+// signs the report and stores it in the data directory, GetOffboardingReports returns it. Should the report not be
+// stored, the job results still tell what was deleted. Dry runs are processed right away and store no report.
+func (cs *CleanupService) DeleteUserResources(ctx context.Context, targetUserId string, userId string, authToken string) (job lib.Job, err error) {
+	if err = checkReportUserId(targetUserId); err != nil {
+		return
+	}
+	items, err := cs.offboardingItems(ctx, targetUserId, nil, userId, authToken)
+	if err != nil {
+		return
+	}
+	return cs.startJob(ctx, JobTypeOffboarding, userId, items)
+}
+
+// offboardingItems returns one item per pipeline of targetUserId, limited to pipelineIds unless nil, followed by the
+// report item. Pipelines of pipelineIds that are gone by now are skipped. The report covers the returned items only.
+func (cs *CleanupService) offboardingItems(ctx context.Context, targetUserId string, pipelineIds []string, userId string, authToken string) (items []jobItem, err error) {
+	key := cs.config.ReportSigningKey.Value()
+	if key == "" {
+		return nil, lib.NewInternalError(errors.New("report signing key not configured"))
+	}
+	inv, err := cs.loadPipelineInventory(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	instances, err := cs.serving.GetInstances(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	var userPipes, otherPipes []pipeModels.Pipeline
+	for _, pipe := range inv.pipes {
+		if pipe.UserId == targetUserId {
+			userPipes = append(userPipes, pipe)
+		} else {
+			otherPipes = append(otherPipes, pipe)
+		}
+	}
+	expected := getExpectedTopics(otherPipes, instances)
+	report := &lib.OffboardingReport{UserId: targetUserId, StartedAt: time.Now().UTC(), Complete: true, DryRun: cs.IsDryRun(ctx)}
+	found := map[string]bool{}
+	for _, pipe := range userPipes {
+		if pipelineIds != nil && !slices.Contains(pipelineIds, pipe.Id) {
+			continue
+		}
+		found[pipe.Id] = true
+		items = append(items, jobItem{JobItem: lib.JobItem{Kind: lib.ResourceKindPipeline, Id: pipe.Id}, run: func(ctx context.Context) lib.JobResult {
+			return cs.offboardPipeline(ctx, inv, pipe, expected, report, authToken)
+		}})
+	}
+	for _, id := range pipelineIds {
+		if !found[id] {
+			items = append(items, skippedJobItem(lib.JobItem{Kind: lib.ResourceKindPipeline, Id: id}, "no longer a pipeline of user "+targetUserId))
+		}
+	}
+	return append(items, cs.reportJobItem(report, key)), nil
+}
+
+// offboardPipeline deletes pipe with its resources and adds the outcome to report.
+func (cs *CleanupService) offboardPipeline(ctx context.Context, inv pipelineInventory, pipe pipeModels.Pipeline, expected map[string]bool, report *lib.OffboardingReport, authToken string) lib.JobResult {
+	pipeReport := lib.PipelineDeleteReport{PipelineId: pipe.Id, Name: pipe.Name}
+	res, err := cs.resolvePipelineResources(ctx, inv, pipe.Id)
+	if err != nil {
+		pipeReport.Results = []lib.DeleteResult{newDeleteResult(lib.ResourceKindPipeline, pipe.Id, err)}
+	} else {
+		pipeReport.Results = cs.deletePipelineResources(ctx, newCascadeDeletion(res, expected), authToken)
+	}
+	report.Pipelines = append(report.Pipelines, pipeReport)
+	result := lib.JobResult{Kind: lib.ResourceKindPipeline, Id: pipe.Id, Status: lib.DeleteResultDeleted, Reason: "pipeline of user " + report.UserId}
+	if err = failedDeletes(pipeReport.Results); err != nil {
+		report.Complete = false
+		result.Status = lib.DeleteResultFailed
+		result.Error = err.Error()
+	} else if report.DryRun {
+		result.Status = lib.DeleteResultWouldDelete
+	}
+	return result
+}
+
+// reportJobItem signs report and stores it, it runs after the pipelines of report were processed.
+// A report that can not be stored fails the item and is logged, so it is not lost.
+func (cs *CleanupService) reportJobItem(report *lib.OffboardingReport, key string) jobItem {
+	return jobItem{JobItem: lib.JobItem{Kind: lib.JobItemKindReport, Id: report.UserId}, run: func(context.Context) lib.JobResult {
+		result := lib.JobResult{Kind: lib.JobItemKindReport, Id: report.UserId, Status: lib.JobResultStored}
+		report.FinishedAt = time.Now().UTC()
+		signature, err := signReport(*report, key)
+		if err == nil && report.DryRun {
+			result.Status = lib.JobResultSkipped
+			result.Error = "dry run reports are not stored"
+			return result
+		}
+		if err == nil {
+			report.Signature = signature
+			result.Reason, err = cs.storeReport(*report)
+		}
+		if err != nil {
+			util.Logger.Error("could not store offboarding report", "error", err, "report", *report)
+			result.Status = lib.DeleteResultFailed
+			result.Error = err.Error()
+			return result
+		}
+		util.Logger.Info("offboarded user", "user", report.UserId, "pipelines", len(report.Pipelines), "complete", report.Complete)
+		return result
+	}}
+}
+
+// resumeOffboardingItems rebuilds the pending items of an offboarding job. The stored report covers the resumed
+// pipelines only, the pipelines processed before are in the job results.
+func (cs *CleanupService) resumeOffboardingItems(ctx context.Context, pending []lib.JobItem, userId string, token string) (items []jobItem, err error) {
+	pipelineIds := []string{}
+	targetUserId := ""
+	for _, item := range pending {
+		if item.Kind == lib.JobItemKindReport {
+			targetUserId = item.Id
+		} else {
+			pipelineIds = append(pipelineIds, item.Id)
+		}
+	}
+	if targetUserId == "" {
+		return
+	}
+	return cs.offboardingItems(ctx, targetUserId, pipelineIds, userId, token)
+}
+
+// GetOffboardingReports returns the stored reports of targetUserId, oldest first.
+func (cs *CleanupService) GetOffboardingReports(targetUserId string) (reports []lib.OffboardingReport, err error) {
+	if err = checkReportUserId(targetUserId); err != nil {
+		return
+	}
+	reports = []lib.OffboardingReport{}
+	entries, err := os.ReadDir(cs.reportDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return reports, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "offboarding-"+targetUserId+"-") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(cs.reportDir(), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var report lib.OffboardingReport
+		if err = json.Unmarshal(data, &report); err != nil {
+			return nil, err
+		}
+		// the prefix of a user id followed by a dash matches the reports of other users as well
+		if report.UserId == targetUserId {
+			reports = append(reports, report)
+		}
+	}
+	return
+}
+
+// checkReportUserId rejects ids that can not be part of a report file name.
+func checkReportUserId(userId string) error {
+	if userId == "" || filepath.Base(userId) != userId {
+		return lib.NewInputError(errors.New("invalid user id " + userId))
+	}
+	return nil
+}
+
+func signReport(report lib.OffboardingReport, key string) (signature string, err error) {
+	report.Signature = ""
+	data, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (cs *CleanupService) reportDir() string {
+	return filepath.Join(cs.config.DataDir, "reports")
+}
+
+// storeReport writes report to the report directory and returns the name of its file.
+func (cs *CleanupService) storeReport(report lib.OffboardingReport) (name string, err error) {
+	if err = os.MkdirAll(cs.reportDir(), 0755); err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return
+	}
+	name = "offboarding-" + report.UserId + "-" + report.StartedAt.Format("20060102T150405Z") + ".json"
+	return name, os.WriteFile(filepath.Join(cs.reportDir(), name), data, 0644)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func TestOffboardingReportItem(t *testing.T) {
+	cs := newTestService(t, newFakeDriver())
+	started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, userId := range []string{"a", "a-b"} {
+		report := &lib.OffboardingReport{UserId: userId, StartedAt: started, Complete: true}
+		result := cs.reportJobItem(report, "key").run(context.Background())
+		if result.Status != lib.JobResultStored || result.Reason != "offboarding-"+userId+"-20250102T030405Z.json" {
+			t.Fatalf("unexpected result %+v", result)
+		}
+	}
+	reports, err := cs.GetOffboardingReports("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].UserId != "a" {
+		t.Fatalf("unexpected reports %+v", reports)
+	}
+	if signature, _ := signReport(reports[0], "key"); signature != reports[0].Signature {
+		t.Fatal("stored report does not match its signature")
+	}
+
+	dryRun := cs.reportJobItem(&lib.OffboardingReport{UserId: "c", DryRun: true}, "key").run(context.Background())
+	if dryRun.Status != lib.JobResultSkipped {
+		t.Fatalf("dry run report stored %+v", dryRun)
+	}
+	if reports, _ = cs.GetOffboardingReports("c"); len(reports) != 0 {
+		t.Fatalf("unexpected reports %+v", reports)
+	}
+}
+
+func TestOffboardingReportNotStored(t *testing.T) {
+	cs := newTestService(t, newFakeDriver())
+	// the reports directory can not be created below a file
+	cs.config.DataDir = filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(cs.config.DataDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	result := cs.reportJobItem(&lib.OffboardingReport{UserId: "a"}, "key").run(context.Background())
+	if result.Status != lib.DeleteResultFailed || result.Error == "" {
+		t.Fatalf("expected failed result, got %+v", result)
+	}
+}
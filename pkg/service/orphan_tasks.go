@@ -0,0 +1,206 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+// OrphanKinds lists every kind DetectOrphans and DeleteOrphans accept.
+var OrphanKinds = []string{
+	OrphanKindPipelines,
+	OrphanKindOwnerlessPipelines,
+	OrphanKindDanglingFlows,
+	OrphanKindAnalyticsWorkloads,
+	OrphanKindServingWorkloads,
+	OrphanKindServingKubeServices,
+	OrphanKindKubeServices,
+	OrphanKindKafkaTopics,
+	OrphanKindKafkaOutputTopics,
+	OrphanKindKafkaConsumerGroups,
+}
+
+// orphanTask detects the orphans of one kind and deletes a single one by its key. remove is nil for kinds that can
+// only be detected.
+type orphanTask struct {
+	detect func() ([]lib.OrphanEntry, error)
+	remove func(id string) error
+}
+
+func (cs *CleanupService) getOrphanTask(ctx context.Context, kind string, userId string, token string) (orphanTask, error) {
+	switch kind {
+	case OrphanKindPipelines:
+		return newOrphanTask(kind, func() ([]lib.Orphan[pipeModels.Pipeline], error) {
+			return cs.GetOrphanedPipelineServices(ctx, userId, token)
+		}, pipelineKey, func(id string) error {
+			return cs.DeleteOrphanedPipelineService(ctx, id, userId, token)
+		}), nil
+	case OrphanKindOwnerlessPipelines:
+		return newOrphanTask(kind, func() ([]lib.Orphan[lib.OwnerlessPipeline], error) {
+			return cs.GetOwnerlessPipelines(ctx, userId, token)
+		}, ownerlessPipelineKey, func(id string) error {
+			results, err := cs.DeletePipeline(ctx, id, true, userId, token)
+			if err != nil {
+				return err
+			}
+			if err = failedDeletes(results); err != nil {
+				return err
+			}
+			if cs.IsDryRun(ctx) {
+				return ErrDryRun
+			}
+			return nil
+		}), nil
+	case OrphanKindDanglingFlows:
+		return newOrphanTask(kind, func() ([]lib.Orphan[pipeModels.Pipeline], error) {
+			return cs.GetPipelinesWithDanglingFlows(ctx, userId, token)
+		}, pipelineKey, nil), nil
+	case OrphanKindAnalyticsWorkloads:
+		return newOrphanTask(kind, func() ([]lib.Orphan[lib.Workload], error) {
+			return cs.GetOrphanedAnalyticsWorkloads(ctx, userId, token)
+		}, workloadKey, func(id string) error {
+			return cs.DeleteOrphanedAnalyticsWorkload(ctx, id)
+		}), nil
+	case OrphanKindServingWorkloads:
+		return newOrphanTask(kind, func() ([]lib.Orphan[lib.Workload], error) {
+			return cs.GetOrphanedServingWorkloads(ctx, userId, token)
+		}, workloadKey, func(id string) error {
+			return cs.DeleteOrphanedServingWorkload(ctx, id)
+		}), nil
+	case OrphanKindServingKubeServices:
+		return newOrphanTask(kind, func() ([]lib.Orphan[lib.KubeService], error) {
+			return cs.GetOrphanedServingKubeServices(ctx, userId, token)
+		}, serviceKey, func(id string) error {
+			return cs.DeleteOrphanedKubeService(ctx, lib.SERVING, id)
+		}), nil
+	case OrphanKindKubeServices:
+		return newOrphanTask(kind, func() ([]lib.Orphan[lib.KubeService], error) {
+			return cs.GetOrphanedKubeServices(ctx, lib.PIPELINE)
+		}, serviceKey, func(id string) error {
+			return cs.DeleteOrphanedKubeService(ctx, lib.PIPELINE, id)
+		}), nil
+	case OrphanKindKafkaTopics:
+		return newOrphanTask(kind, func() ([]lib.Orphan[string], error) {
+			return cs.GetOrphanedKafkaTopics(ctx)
+		}, topicKey, func(id string) error {
+			return cs.DeleteOrphanedKafkaTopic(ctx, id)
+		}), nil
+	case OrphanKindKafkaOutputTopics:
+		return newOrphanTask(kind, func() ([]lib.Orphan[string], error) {
+			return cs.GetOrphanedKafkaOutputTopics(ctx, userId, token)
+		}, topicKey, func(id string) error {
+			return cs.DeleteOrphanedKafkaOutputTopic(ctx, id)
+		}), nil
+	case OrphanKindKafkaConsumerGroups:
+		return newOrphanTask(kind, func() ([]lib.Orphan[lib.ConsumerGroup], error) {
+			return cs.GetOrphanedKafkaConsumerGroups(ctx)
+		}, groupKey, func(id string) error {
+			return cs.DeleteOrphanedKafkaConsumerGroup(ctx, id)
+		}), nil
+	}
+	return orphanTask{}, lib.NewInputError(errors.New("unknown kind " + kind))
+}
+
+func newOrphanTask[T any](kind string, detect func() ([]lib.Orphan[T], error), key func(T) string, remove func(id string) error) orphanTask {
+	return orphanTask{
+		detect: func() (entries []lib.OrphanEntry, err error) {
+			orphans, err := detect()
+			if err != nil {
+				return
+			}
+			for _, orphan := range orphans {
+				entries = append(entries, lib.OrphanEntry{Kind: kind, Id: key(orphan.Resource), OrphanState: orphan.OrphanState})
+			}
+			return
+		},
+		remove: remove,
+	}
+}
+
+// DetectOrphans returns the orphans of kind, keyed the way DeleteOrphans deletes them.
+func (cs *CleanupService) DetectOrphans(ctx context.Context, kind string, userId string, token string) (entries []lib.OrphanEntry, err error) {
+	task, err := cs.getOrphanTask(ctx, kind, userId, token)
+	if err != nil {
+		return
+	}
+	return task.detect()
+}
+
+// DeleteOrphans detects the orphans of kind and deletes the ones that passed the grace period. The deletes run as job
+// like the other bulk deletes, so they are listed in the job history, never overlap a running delete of the same kind
+// and pause between topics. DeleteOrphans waits until the job finished, no job is started without deletable orphans.
+// A failing delete does not stop the remaining ones, the outcome is reported per orphan.
+func (cs *CleanupService) DeleteOrphans(ctx context.Context, kind string, userId string, token string) (entries []lib.OrphanEntry, results []lib.DeleteResult, err error) {
+	task, err := cs.getOrphanTask(ctx, kind, userId, token)
+	if err != nil {
+		return
+	}
+	if task.remove == nil {
+		return nil, nil, lib.NewInputError(errors.New("delete not supported for " + kind))
+	}
+	jobType := orphanJobType(kind)
+	entries, err = task.detect()
+	if err != nil {
+		return
+	}
+	var items []jobItem
+	for _, entry := range entries {
+		if !entry.Deletable {
+			continue
+		}
+		items = append(items, deleteJobItem(kind, entry.Id, orphanReason(entry.OrphanState), func(ctx context.Context, id string) error {
+			// bound to the job, so stopping it aborts the delete
+			deletion, err := cs.getJobOrphanTask(ctx, jobType, userId, token)
+			if err != nil {
+				return err
+			}
+			return deletion.remove(id)
+		}))
+	}
+	if len(items) == 0 {
+		return
+	}
+	job, err := cs.startJob(ctx, jobType, userId, items)
+	if err != nil {
+		return
+	}
+	if !job.DryRun {
+		if job, err = cs.jobs.wait(ctx, job.Id); err != nil {
+			return
+		}
+	}
+	for _, result := range job.Results {
+		results = append(results, lib.DeleteResult(result))
+	}
+	if len(job.Pending) > 0 {
+		err = errors.New(kind + " job " + job.Id + " is " + job.Status + " with " + strconv.Itoa(len(job.Pending)) + " pending deletes")
+	}
+	return
+}
+
+// orphanJobType returns the type of the bulk delete job of kind, the one its delete endpoint starts.
+func orphanJobType(kind string) string {
+	if kind == OrphanKindKubeServices {
+		return OrphanKindKubeServices + ":" + lib.PIPELINE
+	}
+	return kind
+}
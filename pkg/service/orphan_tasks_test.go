@@ -0,0 +1,71 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func TestDeleteOrphansRunsAsJob(t *testing.T) {
+	driver := newFakeDriver()
+	driver.services[lib.PIPELINE] = []lib.KubeService{{Id: "pipes:s1", TargetWorkloadIds: []string{"deployment:pipes:op-1"}}}
+	cs := newTestService(t, driver)
+	enableJobs(t, cs)
+	ctx := context.Background()
+
+	// a running delete job of the same kind blocks the scheduled delete
+	started := make(chan struct{})
+	blocking := jobItem{JobItem: lib.JobItem{Kind: lib.ResourceKindService, Id: "blocking"}, run: func(ctx context.Context) lib.JobResult {
+		close(started)
+		<-ctx.Done()
+		return lib.JobResult{Kind: lib.ResourceKindService, Id: "blocking", Status: lib.JobResultSkipped}
+	}}
+	running, err := cs.startJob(ctx, OrphanKindKubeServices+":"+lib.PIPELINE, "admin", []jobItem{blocking})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	_, _, err = cs.DeleteOrphans(ctx, OrphanKindKubeServices, "service-account", "token")
+	var conflict *lib.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a conflict with the running job, got %v", err)
+	}
+	if err = cs.StopJob(running.Id); err != nil {
+		t.Fatal(err)
+	}
+	waitForJob(t, cs, running.Id)
+
+	_, results, err := cs.DeleteOrphans(ctx, OrphanKindKubeServices, "service-account", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Status != lib.DeleteResultDeleted {
+		t.Fatalf("expected the orphaned service to be deleted, got %+v", results)
+	}
+	if !slices.Equal(driver.deleted, []string{"service:pipes:s1"}) {
+		t.Fatalf("unexpected deletes %v", driver.deleted)
+	}
+	jobs := cs.GetJobs(OrphanKindKubeServices + ":" + lib.PIPELINE)
+	if len(jobs) != 2 || jobs[0].Status != lib.JobStatusCompleted || jobs[0].UserId != "service-account" {
+		t.Fatalf("expected the delete in the job history, got %+v", jobs)
+	}
+}
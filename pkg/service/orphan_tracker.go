@@ -0,0 +1,192 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+const (
+	OrphanKindPipelines           = "pipelines"
+	OrphanKindOwnerlessPipelines  = "ownerlesspipelines"
+	OrphanKindDanglingFlows       = "danglingflows"
+	OrphanKindAnalyticsWorkloads  = "analyticsworkloads"
+	OrphanKindServingWorkloads    = "servingworkloads"
+	OrphanKindServingKubeServices = "servingkubeservices"
+	OrphanKindKubeServices        = "kubeservices"
+	OrphanKindKafkaTopics         = "kafkatopics"
+	OrphanKindKafkaOutputTopics   = "kafkaoutputtopics"
+	OrphanKindKafkaConsumerGroups = "kafkaconsumergroups"
+)
+
+type orphanRecord struct {
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	LastScan  time.Time `json:"lastScan"`
+	Scans     int       `json:"scans"`
+}
+
+// OrphanTracker remembers when each orphan candidate was first and last seen. A candidate only becomes
+// deletable after it was reported as orphaned for gracePeriod and in at least minScans consecutive scans.
+// Sightings closer than scanInterval to the last counted scan do not count as another scan, so listings and dry runs
+// in between do not wear the protection down. Candidates missing from a scan of the same scope are forgotten, so a
+// resource that reappears starts over.
+type OrphanTracker struct {
+	path         string
+	gracePeriod  time.Duration
+	minScans     int
+	scanInterval time.Duration
+	records      map[string]map[string]orphanRecord
+	mu           sync.Mutex
+}
+
+// NewOrphanTracker loads the tracked candidates from path. An empty path keeps the state in memory only.
+func NewOrphanTracker(path string, gracePeriod time.Duration, minScans int, scanInterval time.Duration) (*OrphanTracker, error) {
+	t := &OrphanTracker{
+		path:         path,
+		gracePeriod:  gracePeriod,
+		minScans:     minScans,
+		scanInterval: scanInterval,
+		records:      map[string]map[string]orphanRecord{},
+	}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &t.records); err != nil {
+		return nil, errors.New("could not parse " + path + ": " + err.Error())
+	}
+	return t, nil
+}
+
+// observe records one scan of kind, in which keys were reported as orphaned. Scans with a scope, the user the
+// detection ran for, are tracked apart from each other, so they do not forget the candidates of other scopes.
+func (t *OrphanTracker) observe(kind string, scope string, keys []string) map[string]lib.OrphanState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if scope != "" {
+		kind += "@" + scope
+	}
+	now := time.Now()
+	previous := t.records[kind]
+	current := map[string]orphanRecord{}
+	states := map[string]lib.OrphanState{}
+	for _, key := range keys {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		record, ok := previous[key]
+		if !ok {
+			record.FirstSeen = now
+		}
+		record.LastSeen = now
+		if record.Scans == 0 || now.Sub(record.LastScan) >= t.scanInterval {
+			record.LastScan = now
+			record.Scans++
+		}
+		current[key] = record
+		states[key] = lib.OrphanState{
+			FirstSeen: record.FirstSeen,
+			LastSeen:  record.LastSeen,
+			Scans:     record.Scans,
+			Deletable: now.Sub(record.FirstSeen) >= t.gracePeriod && record.Scans >= t.minScans,
+		}
+	}
+	t.records[kind] = current
+	if err := t.save(); err != nil {
+		util.Logger.Error("could not persist orphan state", "error", err)
+	}
+	return states
+}
+
+func (t *OrphanTracker) save() (err error) {
+	if t.path == "" {
+		return
+	}
+	data, err := json.Marshal(t.records)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return
+	}
+	tmp := t.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, t.path)
+}
+
+func trackOrphans[T any](t *OrphanTracker, kind string, scope string, resources []T, key func(T) string) (orphans []lib.Orphan[T]) {
+	keys := make([]string, len(resources))
+	for i, resource := range resources {
+		keys[i] = key(resource)
+	}
+	states := t.observe(kind, scope, keys)
+	for i, resource := range resources {
+		orphans = append(orphans, lib.Orphan[T]{Resource: resource, OrphanState: states[keys[i]]})
+	}
+	return
+}
+
+func deletableOrphans[T any](orphans []lib.Orphan[T]) (resources []T) {
+	for _, orphan := range orphans {
+		if orphan.Deletable {
+			resources = append(resources, orphan.Resource)
+		}
+	}
+	return
+}
+
+func topicKey(topic string) string {
+	return topic
+}
+
+func workloadKey(workload lib.Workload) string {
+	return workload.Id
+}
+
+func serviceKey(service lib.KubeService) string {
+	return service.Id
+}
+
+func pipelineKey(pipe pipeModels.Pipeline) string {
+	return pipe.Id
+}
+
+func ownerlessPipelineKey(o lib.OwnerlessPipeline) string {
+	return o.Pipeline.Id
+}
+
+func groupKey(group lib.ConsumerGroup) string {
+	return group.Id
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserveCountsOneScanPerInterval(t *testing.T) {
+	tracker, err := NewOrphanTracker("", 0, 2, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		tracker.observe(OrphanKindKafkaTopics, "", []string{"topic"})
+	}
+	state := tracker.observe(OrphanKindKafkaTopics, "", []string{"topic"})["topic"]
+	if state.Scans != 1 || state.Deletable {
+		t.Fatalf("expected repeated sightings within the interval to count once, got %+v", state)
+	}
+	tracker.scanInterval = 0
+	state = tracker.observe(OrphanKindKafkaTopics, "", []string{"topic"})["topic"]
+	if state.Scans != 2 || !state.Deletable {
+		t.Fatalf("expected second scan after the interval, got %+v", state)
+	}
+}
+
+func TestObserveKeepsOtherScopes(t *testing.T) {
+	tracker, err := NewOrphanTracker("", 0, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.observe(OrphanKindPipelines, "user-a", []string{"p1"})
+	tracker.observe(OrphanKindPipelines, "user-b", []string{"p2"})
+	state := tracker.observe(OrphanKindPipelines, "user-a", []string{"p1"})["p1"]
+	if state.Scans != 2 {
+		t.Fatalf("expected scan of user-b to keep the progress of user-a, got %+v", state)
+	}
+	tracker.observe(OrphanKindPipelines, "user-a", nil)
+	state = tracker.observe(OrphanKindPipelines, "user-b", []string{"p2"})["p2"]
+	if state.Scans != 2 {
+		t.Fatalf("expected scan of user-a to keep the progress of user-b, got %+v", state)
+	}
+	state = tracker.observe(OrphanKindPipelines, "user-a", []string{"p1"})["p1"]
+	if state.Scans != 1 {
+		t.Fatalf("expected p1 to start over after missing from a scan of user-a, got %+v", state)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+const (
+	OwnershipRuleLabel = "label"
+	OwnershipRuleEnv   = "env"
+	OwnershipRuleName  = "name"
+)
+
+var DefaultOwnershipRules = []string{"label:pipelineId", "env:CONFIG_PIPELINE_ID", "env:CONFIG_APPLICATION_ID", "name"}
+
+var uuidRx = regexp.MustCompile("[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}")
+
+func isPipelineUuid(id string) bool {
+	return len(id) == 36 && uuidRx.MatchString(id)
+}
+
+type ownershipRule struct {
+	kind string
+	key  string
+}
+
+func (r ownershipRule) String() string {
+	if r.key == "" {
+		return r.kind
+	}
+	return r.kind + ":" + r.key
+}
+
+// OwnershipMatcher decides which pipeline a workload belongs to. Rules are evaluated in order,
+// the first label or env rule present on a workload is authoritative, name matching is only
+// used if no earlier rule applies.
+type OwnershipMatcher struct {
+	rules []ownershipRule
+}
+
+// NewOwnershipMatcher parses rules of the form "label:<key>", "env:<key>" or "name".
+func NewOwnershipMatcher(rules []string) (*OwnershipMatcher, error) {
+	if len(rules) == 0 {
+		rules = DefaultOwnershipRules
+	}
+	m := &OwnershipMatcher{}
+	for _, rule := range rules {
+		kind, key, _ := strings.Cut(rule, ":")
+		switch kind {
+		case OwnershipRuleLabel, OwnershipRuleEnv:
+			if key == "" {
+				return nil, errors.New("ownership rule " + rule + " is missing a key")
+			}
+		case OwnershipRuleName:
+		default:
+			return nil, errors.New("unknown ownership rule " + rule)
+		}
+		m.rules = append(m.rules, ownershipRule{kind: kind, key: key})
+	}
+	return m, nil
+}
+
+// Match reports whether workload belongs to pipe and the rule that decided it.
+func (m *OwnershipMatcher) Match(workload lib.Workload, pipe pipeModels.Pipeline) (rule string, ok bool) {
+	for _, r := range m.rules {
+		var value string
+		switch r.kind {
+		case OwnershipRuleLabel:
+			value = workload.Labels[r.key]
+		case OwnershipRuleEnv:
+			value = workload.Environment[r.key]
+		case OwnershipRuleName:
+			if pipe.Id != "" && strings.Contains(workload.Name, pipe.Id) {
+				return r.String(), true
+			}
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		return r.String(), getPipelineId(value) == pipe.Id
+	}
+	return "", false
+}
+
+// matchExact is Match without the name rule. Deletes of a pipeline's resources only trust labels and env.
+func (m *OwnershipMatcher) matchExact(workload lib.Workload, pipe pipeModels.Pipeline) bool {
+	rule, ok := m.Match(workload, pipe)
+	return ok && rule != OwnershipRuleName
+}
+
+// Owner returns the pipeline of pipes that workload belongs to.
+func (m *OwnershipMatcher) Owner(workload lib.Workload, pipes []pipeModels.Pipeline) (match lib.OwnershipMatch, ok bool) {
+	for _, pipe := range pipes {
+		if rule, ok := m.Match(workload, pipe); ok {
+			return lib.OwnershipMatch{
+				WorkloadId:   workload.Id,
+				WorkloadName: workload.Name,
+				PipelineId:   pipe.Id,
+				Rule:         rule,
+			}, true
+		}
+	}
+	return lib.OwnershipMatch{WorkloadId: workload.Id, WorkloadName: workload.Name}, false
+}
+
+func (m *OwnershipMatcher) pipeInWorkloads(pipe pipeModels.Pipeline, workloads []lib.Workload) bool {
+	for _, workload := range workloads {
+		if _, ok := m.Match(workload, pipe); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *OwnershipMatcher) workloadInPipes(workload lib.Workload, pipes []pipeModels.Pipeline) bool {
+	_, ok := m.Owner(workload, pipes)
+	return ok
+}
+
+// getPipelineId extracts the pipeline id from label or env values like analytics-<pipelineId>-<operatorId>.
+func getPipelineId(value string) string {
+	if id := uuidRx.FindString(value); id != "" {
+		return id
+	}
+	return value
+}
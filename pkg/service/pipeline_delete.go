@@ -0,0 +1,203 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+// pipelineDeletion lists the resources of one pipeline in the order they are deleted.
+type pipelineDeletion struct {
+	pipeId    string
+	workloads []lib.Workload
+	services  []lib.KubeService
+	pipe      *pipeModels.Pipeline
+	topics    []string
+	groups    []lib.ConsumerGroup
+}
+
+// DeletePipeline deletes the registry entry of pipeline id. With cascade, the workloads, services only targeting them,
+// internal topics, output topics nobody else uses and consumer groups are deleted as well. Every resource is attempted
+// even if a previous one failed, the outcome is reported per resource.
+func (cs *CleanupService) DeletePipeline(ctx context.Context, id string, cascade bool, userId string, authToken string) (results []lib.DeleteResult, err error) {
+	if !cascade {
+		err = cs.deletePipelineById(ctx, id, userId, authToken)
+		if err != nil && !errors.Is(err, ErrDryRun) {
+			return
+		}
+		return []lib.DeleteResult{newDeleteResult(lib.ResourceKindPipeline, id, err)}, nil
+	}
+	inv, err := cs.loadPipelineInventory(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	res, err := cs.resolvePipelineResources(ctx, inv, id)
+	if err != nil {
+		return
+	}
+	if err = checkCascade(res); err != nil {
+		return
+	}
+	instances, err := cs.serving.GetInstances(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	deletion := newCascadeDeletion(res, getExpectedTopics(res.otherPipes, instances))
+	results = cs.deletePipelineResources(ctx, deletion, authToken)
+	if failed := failedDeletes(results); failed != nil {
+		util.Logger.Error("cascading delete incomplete", "pipeline", id, "error", failed)
+	}
+	return
+}
+
+// checkCascade refuses cascades for ids that are neither in the registry nor a pipeline uuid. Internal topics and
+// consumer groups are only recognized by uuid, anything else would delete resources that merely resemble the id.
+func checkCascade(res pipelineResources) error {
+	if res.pipe == nil && !isPipelineUuid(res.id) {
+		return lib.NewNotFoundError(errors.New("pipeline " + res.id + " not found in registry"))
+	}
+	if res.empty() {
+		return lib.NewNotFoundError(errors.New("pipeline " + res.id + " not found"))
+	}
+	return nil
+}
+
+// newCascadeDeletion selects all resources of res, except services shared with other workloads and output topics in expected.
+// Workloads only matched by name are left alone, a name containing the pipeline id does not prove ownership.
+func newCascadeDeletion(res pipelineResources, expected map[string]bool) pipelineDeletion {
+	deletion := pipelineDeletion{
+		pipeId: res.id,
+		pipe:   res.pipe,
+		topics: append([]string{}, res.internalTopics...),
+		groups: res.groups,
+	}
+	otherWorkloads := res.otherWorkloads
+	for _, match := range res.workloads {
+		if match.rule == OwnershipRuleName {
+			otherWorkloads = append(otherWorkloads, match.workload)
+			continue
+		}
+		deletion.workloads = append(deletion.workloads, match.workload)
+	}
+	deletion.services = exclusiveServices(res.services, deletion.workloads, otherWorkloads)
+	for _, topic := range res.outputTopics {
+		if !expected[topic] {
+			deletion.topics = append(deletion.topics, topic)
+		}
+	}
+	return deletion
+}
+
+// newPipelineDeletion selects the workloads of pipe matched by label or env and the services only targeting them.
+func (cs *CleanupService) newPipelineDeletion(pipe pipeModels.Pipeline, workloads []lib.Workload, services []lib.KubeService) pipelineDeletion {
+	var pipeWorkloads, otherWorkloads []lib.Workload
+	for _, workload := range workloads {
+		if cs.ownership.matchExact(workload, pipe) {
+			pipeWorkloads = append(pipeWorkloads, workload)
+		} else {
+			otherWorkloads = append(otherWorkloads, workload)
+		}
+	}
+	return pipelineDeletion{
+		pipeId:    pipe.Id,
+		workloads: pipeWorkloads,
+		services:  exclusiveServices(services, pipeWorkloads, otherWorkloads),
+		pipe:      &pipe,
+	}
+}
+
+// deletePipelineResources deletes workloads first, so nothing recreates topics or rejoins consumer groups afterward.
+// Resources that are already gone count as deleted.
+func (cs *CleanupService) deletePipelineResources(ctx context.Context, d pipelineDeletion, authToken string) (results []lib.DeleteResult) {
+	remove := func(kind string, id string, reason string, remove func() error) {
+		result := newDeleteResult(kind, id, remove())
+		result.Reason = reason
+		results = append(results, result)
+	}
+	for _, workload := range d.workloads {
+		remove(lib.ResourceKindWorkload, workload.Id, "workload of pipeline "+d.pipeId, func() error {
+			return cs.deleteWorkload(ctx, workload.Id, lib.PIPELINE)
+		})
+	}
+	for _, service := range d.services {
+		remove(lib.ResourceKindService, service.Id, "service only targeting workloads of pipeline "+d.pipeId, func() error {
+			return cs.deleteService(ctx, service.Id, lib.PIPELINE)
+		})
+	}
+	if d.pipe != nil {
+		remove(lib.ResourceKindPipeline, d.pipeId, "registry entry of pipeline "+d.pipeId, func() error {
+			return cs.deletePipeline(ctx, *d.pipe, authToken)
+		})
+	}
+	for _, topic := range d.topics {
+		remove(lib.ResourceKindTopic, topic, "topic of pipeline "+d.pipeId+" not used by others", func() error {
+			return cs.deleteTopic(ctx, topic)
+		})
+	}
+	for _, group := range d.groups {
+		// consumer groups are not quarantined, the pipeline creates them again on restore
+		remove(lib.ResourceKindConsumerGroup, group.Id, "consumer group of pipeline "+d.pipeId, func() error {
+			return cs.guardDelete(ctx, func() error {
+				return cs.kafkaAdmin.DeleteConsumerGroup(ctx, group.Id)
+			})
+		})
+	}
+	return
+}
+
+func exclusiveServices(services []lib.KubeService, pipeWorkloads []lib.Workload, otherWorkloads []lib.Workload) (exclusive []lib.KubeService) {
+	for _, service := range services {
+		if serviceInWorkloads(service, pipeWorkloads) && !serviceInWorkloads(service, otherWorkloads) {
+			exclusive = append(exclusive, service)
+		}
+	}
+	return
+}
+
+func newDeleteResult(kind string, id string, err error) lib.DeleteResult {
+	result := lib.DeleteResult{Kind: kind, Id: id, Status: lib.DeleteResultDeleted}
+	var notFound *lib.NotFoundError
+	var indeterminate *lib.IndeterminateError
+	if errors.Is(err, ErrDryRun) {
+		result.Status = lib.DeleteResultWouldDelete
+	} else if errors.As(err, &notFound) {
+		result.Status = lib.DeleteResultNotFound
+	} else if errors.As(err, &indeterminate) {
+		result.Status = lib.DeleteResultIndeterminate
+		result.Error = err.Error()
+	} else if err != nil {
+		result.Status = lib.DeleteResultFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// failedDeletes joins the errors of all failed results.
+func failedDeletes(results []lib.DeleteResult) error {
+	var errs []error
+	for _, result := range results {
+		if result.Status == lib.DeleteResultFailed {
+			errs = append(errs, errors.New(result.Kind+" "+result.Id+": "+result.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+const testPipeId = "0b7f1c2e-4d5a-4e6f-8a9b-0c1d2e3f4a5b"
+
+func TestCascadeRequiresRegistryOrUuid(t *testing.T) {
+	cs := newTestService(t, newFakeDriver())
+	inv := pipelineInventory{
+		workloads: []lib.Workload{{Id: "deployment:pipes:worker-1", Name: "worker-1", Type: lib.WorkloadTypeDeployment}},
+		topics:    []string{"topic-1"},
+	}
+	res, err := cs.resolvePipelineResources(context.Background(), inv, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notFound *lib.NotFoundError
+	if err = checkCascade(res); !errors.As(err, &notFound) {
+		t.Fatalf("expected not found for unknown short id, got %v", err)
+	}
+	inv.pipes = []pipeModels.Pipeline{{Id: "1"}}
+	res, err = cs.resolvePipelineResources(context.Background(), inv, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = checkCascade(res); err != nil {
+		t.Fatalf("registry pipelines can be cascaded, got %v", err)
+	}
+	// the name of worker-1 contains the id, it must not be deleted with the pipeline
+	if deletion := newCascadeDeletion(res, nil); len(deletion.workloads) != 0 {
+		t.Fatalf("name matched workloads selected %+v", deletion.workloads)
+	}
+}
+
+func TestCascadeOnlyDeletesExactMatches(t *testing.T) {
+	cs := newTestService(t, newFakeDriver())
+	labeled := lib.Workload{Id: "deployment:pipes:op-1", Name: "op-1", Type: lib.WorkloadTypeDeployment, Labels: map[string]string{"pipelineId": testPipeId}}
+	named := lib.Workload{Id: "deployment:pipes:x-" + testPipeId, Name: "x-" + testPipeId, Type: lib.WorkloadTypeDeployment}
+	inv := pipelineInventory{
+		workloads: []lib.Workload{labeled, named},
+		services: []lib.KubeService{
+			{Id: "pipes:op-1", TargetWorkloadIds: []string{labeled.Id}},
+			{Id: "pipes:shared", TargetWorkloadIds: []string{labeled.Id, named.Id}},
+		},
+		topics: []string{"analytics-" + testPipeId + "-op-repartition"},
+	}
+	// dangling resources of a pipeline uuid can be cascaded without registry entry
+	res, err := cs.resolvePipelineResources(context.Background(), inv, testPipeId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = checkCascade(res); err != nil {
+		t.Fatal(err)
+	}
+	deletion := newCascadeDeletion(res, nil)
+	if len(deletion.workloads) != 1 || deletion.workloads[0].Id != labeled.Id {
+		t.Fatalf("unexpected workloads %+v", deletion.workloads)
+	}
+	if len(deletion.services) != 1 || deletion.services[0].Id != "pipes:op-1" {
+		t.Fatalf("unexpected services %+v", deletion.services)
+	}
+	if len(deletion.topics) != 1 || deletion.pipe != nil {
+		t.Fatalf("unexpected deletion %+v", deletion)
+	}
+	ownerless := cs.newPipelineDeletion(pipeModels.Pipeline{Id: testPipeId}, inv.workloads, inv.services)
+	if len(ownerless.workloads) != 1 || ownerless.workloads[0].Id != labeled.Id {
+		t.Fatalf("unexpected ownerless workloads %+v", ownerless.workloads)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+// RecreatePipelines starts a job recreating all pipelines whose workloads vanished, optionally limited to one user or
+// a set of pipeline ids. The candidates are selected immediately, the recreation runs in the background.
+func (cs *CleanupService) RecreatePipelines(ctx context.Context, request lib.RecreateRequest, userId string, authToken string) (job lib.Job, err error) {
+	pipes, err := cs.getRecreateCandidates(ctx, request, userId, authToken)
+	if err != nil {
+		return
+	}
+	var items []jobItem
+	for _, pipe := range pipes {
+		items = append(items, cs.recreateJobItem(pipe))
+	}
+	cs.logger.Print("**************** Recreate Pipelines *********************")
+	return cs.jobs.start(JobTypeRecreatePipelines, userId, items)
+}
+
+func (cs *CleanupService) recreateJobItem(pipe pipeModels.Pipeline) jobItem {
+	return jobItem{JobItem: lib.JobItem{Kind: lib.ResourceKindPipeline, Id: pipe.Id}, run: func(ctx context.Context) lib.JobResult {
+		return cs.recreatePipeline(ctx, pipe)
+	}}
+}
+
+func (cs *CleanupService) GetRecreatePipelinesStatus() (lib.Job, error) {
+	return cs.jobs.Latest(JobTypeRecreatePipelines)
+}
+
+func (cs *CleanupService) StopRecreatePipelines() (err error) {
+	return cs.jobs.StopType(JobTypeRecreatePipelines)
+}
+
+// getRecreateCandidates returns the pipelines without workloads that match request. Pipelines with local operators are skipped.
+func (cs *CleanupService) getRecreateCandidates(ctx context.Context, request lib.RecreateRequest, userId string, authToken string) (candidates []pipeModels.Pipeline, err error) {
+	pipes, err := cs.pipeline.GetPipelines(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	workloads, err := cs.driver.GetWorkloads(ctx, lib.PIPELINE)
+	if err != nil {
+		return
+	}
+	for _, pipe := range pipes {
+		if request.UserId != "" && pipe.UserId != request.UserId {
+			continue
+		}
+		if len(request.PipelineIds) > 0 && !slices.Contains(request.PipelineIds, pipe.Id) {
+			continue
+		}
+		if isLocalPipeline(pipe) || cs.ownership.pipeInWorkloads(pipe, workloads) {
+			continue
+		}
+		candidates = append(candidates, pipe)
+	}
+	return
+}
+
+// recreatePipeline recreates pipe in the name of its owner. Pipelines whose flow is gone are skipped, they can not be recreated.
+func (cs *CleanupService) recreatePipeline(ctx context.Context, pipe pipeModels.Pipeline) (result lib.JobResult) {
+	result = lib.JobResult{Kind: lib.ResourceKindPipeline, Id: pipe.Id, Status: lib.JobResultRecreated}
+	cs._logPrint(pipe.Id, pipe.Name, pipe.UserId)
+	fail := func(err error) lib.JobResult {
+		cs.logger.Print(err.Error() + ", User: " + pipe.UserId + ", Pipeline " + pipe.Id)
+		result.Status = lib.DeleteResultFailed
+		result.Error = err.Error()
+		return result
+	}
+	userToken, err := cs.keycloak.GetImpersonateToken(ctx, pipe.UserId)
+	if err != nil {
+		return fail(err)
+	}
+	if pipe.FlowId != "" {
+		missing, err := cs.flowMissing(ctx, pipe.FlowId, pipe.UserId, userToken)
+		if err != nil {
+			return fail(err)
+		}
+		if missing {
+			result.Status = lib.JobResultSkipped
+			result.Error = "flow " + pipe.FlowId + " not found"
+			return
+		}
+	}
+	request := (&lib.Pipeline{Pipeline: pipe}).ToRequest()
+	err = cs.pipeline.CreatePipeline(ctx, request, pipe.UserId, userToken)
+	if err != nil {
+		return fail(err)
+	}
+	util.Logger.Info("recreated pipeline", "pipeline", pipe.Id, "user", pipe.UserId)
+	return
+}
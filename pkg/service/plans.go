@@ -0,0 +1,429 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+	"github.com/google/uuid"
+)
+
+const JobTypeApplyPlan = "plan"
+
+// planCredentialsMaxAge is how long the service credentials are reused when verifying plan items.
+const planCredentialsMaxAge = 30 * time.Second
+
+// PlanStore persists plans, one file per plan in dir.
+type PlanStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewPlanStore(dir string) *PlanStore {
+	return &PlanStore{dir: dir}
+}
+
+func (s *PlanStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// get loads plan id and expires it if needed. Callers must hold s.mu.
+func (s *PlanStore) get(id string) (plan lib.Plan, err error) {
+	// ids are uuids, anything else could point outside of dir
+	if uuid.Validate(id) != nil {
+		return plan, lib.NewNotFoundError(errors.New("plan " + id + " not found"))
+	}
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return plan, lib.NewNotFoundError(errors.New("plan " + id + " not found"))
+	}
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &plan); err != nil {
+		return
+	}
+	err = s.expire(&plan)
+	return
+}
+
+// expire marks plan as expired once it passed ExpiresAt without being applied. Callers must hold s.mu.
+func (s *PlanStore) expire(plan *lib.Plan) error {
+	if plan.ExpiresAt == nil || time.Now().Before(*plan.ExpiresAt) {
+		return nil
+	}
+	if plan.Status != lib.PlanStatusPending && plan.Status != lib.PlanStatusApproved {
+		return nil
+	}
+	expired := time.Now().UTC()
+	plan.Status = lib.PlanStatusExpired
+	plan.ExpiredAt = &expired
+	util.Logger.Info("plan expired", "plan", plan.Id, "approvals", len(plan.Approvals))
+	return s.save(*plan)
+}
+
+// save persists plan. Callers must hold s.mu.
+func (s *PlanStore) save(plan lib.Plan) (err error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(s.dir, 0755); err != nil {
+		return
+	}
+	path := s.path(plan.Id)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, path)
+}
+
+// list loads all plans, newest first. Callers must hold s.mu.
+func (s *PlanStore) list() (plans []lib.Plan, err error) {
+	plans = []lib.Plan{}
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return plans, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		plan, err := s.get(entry.Name()[:len(entry.Name())-len(".json")])
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	slices.SortFunc(plans, func(a, b lib.Plan) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return
+}
+
+// planHash hashes the kinds and item keys of plan. States are left out, they change with every scan.
+func planHash(plan lib.Plan) string {
+	items := make([]lib.JobItem, len(plan.Items))
+	for i, item := range plan.Items {
+		items[i] = lib.JobItem{Kind: item.Kind, Id: item.Id}
+	}
+	data, _ := json.Marshal(struct {
+		Kinds []string      `json:"kinds"`
+		Items []lib.JobItem `json:"items"`
+	}{plan.Kinds, items})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// CreatePlan detects the orphans of the requested kinds and persists the deletable ones as a plan.
+// Without kinds, all kinds that can be deleted are planned. Plans with at least PlanApprovalThreshold items need
+// PlanApprovals approvals before they can be applied.
+func (cs *CleanupService) CreatePlan(ctx context.Context, request lib.PlanRequest, userId string, token string) (plan lib.Plan, err error) {
+	kinds := request.Kinds
+	if len(kinds) == 0 {
+		for _, kind := range OrphanKinds {
+			if kind != OrphanKindDanglingFlows {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	plan = lib.Plan{
+		Id:        uuid.NewString(),
+		UserId:    userId,
+		CreatedAt: time.Now().UTC(),
+		Kinds:     slices.Compact(slices.Sorted(slices.Values(kinds))),
+		Items:     []lib.OrphanEntry{},
+		Approvals: []lib.PlanDecision{},
+	}
+	if cs.config.PlanExpiry > 0 {
+		expires := plan.CreatedAt.Add(cs.config.PlanExpiry)
+		plan.ExpiresAt = &expires
+	}
+	for _, kind := range plan.Kinds {
+		task, err := cs.getOrphanTask(ctx, kind, userId, token)
+		if err != nil {
+			return plan, err
+		}
+		if task.remove == nil {
+			return plan, lib.NewInputError(errors.New("delete not supported for " + kind))
+		}
+		entries, err := task.detect()
+		if err != nil {
+			return plan, err
+		}
+		for _, entry := range entries {
+			if entry.Deletable {
+				plan.Items = append(plan.Items, entry)
+			}
+		}
+	}
+	slices.SortFunc(plan.Items, func(a, b lib.OrphanEntry) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Id, b.Id))
+	})
+	plan.Hash = planHash(plan)
+	plan.Status = lib.PlanStatusApproved
+	if len(plan.Items) >= cs.config.PlanApprovalThreshold && cs.config.PlanApprovals > 0 {
+		plan.RequiredApprovals = cs.config.PlanApprovals
+		plan.Status = lib.PlanStatusPending
+	}
+	cs.plans.mu.Lock()
+	defer cs.plans.mu.Unlock()
+	if err = cs.plans.save(plan); err != nil {
+		return
+	}
+	util.Logger.Info("created plan", "plan", plan.Id, "items", len(plan.Items), "hash", plan.Hash, "status", plan.Status)
+	return
+}
+
+// GetPlans returns all plans without their items, newest first.
+func (cs *CleanupService) GetPlans() (plans []lib.Plan, err error) {
+	cs.plans.mu.Lock()
+	defer cs.plans.mu.Unlock()
+	plans, err = cs.plans.list()
+	for i := range plans {
+		plans[i].Items = nil
+	}
+	return
+}
+
+func (cs *CleanupService) GetPlan(id string) (lib.Plan, error) {
+	cs.plans.mu.Lock()
+	defer cs.plans.mu.Unlock()
+	return cs.plans.get(id)
+}
+
+// ApprovePlan records the approval of plan id by userId. Approvals must come from distinct admins other than the
+// creator. If hash is given, it must match the plan.
+func (cs *CleanupService) ApprovePlan(id string, hash string, userId string) (plan lib.Plan, err error) {
+	cs.plans.mu.Lock()
+	defer cs.plans.mu.Unlock()
+	plan, err = cs.plans.get(id)
+	if err != nil {
+		return
+	}
+	if hash != "" && hash != plan.Hash {
+		return plan, lib.NewInputError(errors.New("hash does not match plan " + id))
+	}
+	if plan.Status != lib.PlanStatusPending {
+		return plan, lib.NewConflictError(errors.New("plan " + id + " is " + plan.Status))
+	}
+	if userId == "" {
+		return plan, lib.NewForbiddenError(errors.New("approvals need a user id"))
+	}
+	if userId == plan.UserId {
+		return plan, lib.NewForbiddenError(errors.New("plan " + id + " can not be approved by its creator"))
+	}
+	for _, approval := range plan.Approvals {
+		if approval.UserId == userId {
+			return plan, lib.NewConflictError(errors.New("plan " + id + " already approved by " + userId))
+		}
+	}
+	plan.Approvals = append(plan.Approvals, lib.PlanDecision{UserId: userId, At: time.Now().UTC()})
+	if len(plan.Approvals) >= plan.RequiredApprovals {
+		plan.Status = lib.PlanStatusApproved
+	}
+	if err = cs.plans.save(plan); err != nil {
+		return
+	}
+	util.Logger.Info("approved plan", "plan", id, "user", userId, "approvals", len(plan.Approvals), "required", plan.RequiredApprovals)
+	return
+}
+
+// RejectPlan rejects plan id, it can not be applied afterward.
+func (cs *CleanupService) RejectPlan(id string, reason string, userId string) (plan lib.Plan, err error) {
+	cs.plans.mu.Lock()
+	defer cs.plans.mu.Unlock()
+	plan, err = cs.plans.get(id)
+	if err != nil {
+		return
+	}
+	if plan.Status != lib.PlanStatusPending && plan.Status != lib.PlanStatusApproved {
+		return plan, lib.NewConflictError(errors.New("plan " + id + " is " + plan.Status))
+	}
+	plan.Status = lib.PlanStatusRejected
+	plan.Rejection = &lib.PlanDecision{UserId: userId, At: time.Now().UTC(), Reason: reason}
+	if err = cs.plans.save(plan); err != nil {
+		return
+	}
+	util.Logger.Info("rejected plan", "plan", id, "user", userId, "reason", reason)
+	return
+}
+
+// ApplyPlan deletes the items of plan id in the background. Only approved plans can be applied, once. If hash is
+// given, it must match the plan, so the plan that was reviewed is the one that gets applied. Each item is verified to
+// be still a deletable orphan right before it is deleted, items that are not are skipped.
+// Dry runs also preview pending plans and leave the plan as it is.
+func (cs *CleanupService) ApplyPlan(ctx context.Context, id string, hash string, userId string) (job lib.Job, err error) {
+	if cs.IsDryRun(ctx) {
+		// the preview runs right away, do not block the other plans meanwhile
+		cs.plans.mu.Lock()
+		plan, err := cs.applicablePlan(id, hash, true)
+		cs.plans.mu.Unlock()
+		if err != nil {
+			return job, err
+		}
+		return cs.startJob(ctx, JobTypeApplyPlan, userId, cs.planItems(plan.UserId, planJobItems(plan)))
+	}
+	cs.plans.mu.Lock()
+	defer cs.plans.mu.Unlock()
+	plan, err := cs.applicablePlan(id, hash, false)
+	if err != nil {
+		return
+	}
+	job, err = cs.startJob(ctx, JobTypeApplyPlan, userId, cs.planItems(plan.UserId, planJobItems(plan)))
+	if err != nil {
+		return
+	}
+	applied := job.CreatedAt
+	plan.Status = lib.PlanStatusApplied
+	plan.AppliedAt = &applied
+	plan.JobId = job.Id
+	if err = cs.plans.save(plan); err != nil {
+		// the job is already running, do not report it as failed
+		util.Logger.Error("could not persist applied plan", "plan", id, "job", job.Id, "error", err)
+		err = nil
+	}
+	util.Logger.Info("applying plan", "plan", id, "job", job.Id, "user", userId)
+	return
+}
+
+// applicablePlan loads plan id and checks that it can be applied. Callers must hold cs.plans.mu.
+func (cs *CleanupService) applicablePlan(id string, hash string, dryRun bool) (plan lib.Plan, err error) {
+	plan, err = cs.plans.get(id)
+	if err != nil {
+		return
+	}
+	if hash != "" && hash != plan.Hash {
+		return plan, lib.NewInputError(errors.New("hash does not match plan " + id))
+	}
+	if planHash(plan) != plan.Hash {
+		return plan, errors.New("plan " + id + " does not match its hash")
+	}
+	if plan.Status == lib.PlanStatusPending && !dryRun {
+		return plan, lib.NewConflictError(errors.New("plan " + id + " needs " + strconv.Itoa(plan.RequiredApprovals-len(plan.Approvals)) + " more approvals"))
+	}
+	if plan.Status != lib.PlanStatusApproved && plan.Status != lib.PlanStatusPending {
+		return plan, lib.NewConflictError(errors.New("plan " + id + " is " + plan.Status))
+	}
+	return
+}
+
+// planOfJob returns the plan applied by job jobId.
+func (cs *CleanupService) planOfJob(jobId string) (plan lib.Plan, err error) {
+	cs.plans.mu.Lock()
+	defer cs.plans.mu.Unlock()
+	plans, err := cs.plans.list()
+	if err != nil {
+		return
+	}
+	for _, plan := range plans {
+		if plan.JobId == jobId {
+			return plan, nil
+		}
+	}
+	return plan, lib.NewNotFoundError(errors.New("no plan applied by job " + jobId))
+}
+
+func planJobItems(plan lib.Plan) []lib.JobItem {
+	items := make([]lib.JobItem, len(plan.Items))
+	for i, item := range plan.Items {
+		items[i] = lib.JobItem{Kind: item.Kind, Id: item.Id}
+	}
+	return items
+}
+
+// planItems creates the job items of a plan created by scope. The items carry their orphan kind and share one verifier.
+func (cs *CleanupService) planItems(scope string, pending []lib.JobItem) (items []jobItem) {
+	verifier := &orphanVerifier{cs: cs, scope: scope}
+	for _, item := range pending {
+		items = append(items, verifier.deleteItem(item))
+	}
+	return
+}
+
+// orphanVerifier deletes plan items after checking they are still deletable orphans. The orphans are detected again
+// for every item, in the scope of the plan creator, whose scans made them deletable. The service token is reused for
+// planCredentialsMaxAge.
+type orphanVerifier struct {
+	cs            *CleanupService
+	scope         string
+	token         string
+	credentialsAt time.Time
+}
+
+func (v *orphanVerifier) deleteItem(item lib.JobItem) jobItem {
+	return jobItem{JobItem: item, run: func(ctx context.Context) lib.JobResult {
+		task, deletable, err := v.verify(ctx, item)
+		if err != nil {
+			return lib.JobResult(newDeleteResult(item.Kind, item.Id, err))
+		}
+		if !deletable {
+			return lib.JobResult{Kind: item.Kind, Id: item.Id, Status: lib.JobResultSkipped, Error: "no longer a deletable orphan"}
+		}
+		result := newDeleteResult(item.Kind, item.Id, task.remove(item.Id))
+		if result.Status == lib.DeleteResultDeleted {
+			util.Logger.Info("deleted planned orphan", "kind", item.Kind, "id", item.Id)
+		}
+		return lib.JobResult(result)
+	}}
+}
+
+// verify returns the task of the kind of item and whether item is still a deletable orphan. The detection runs right
+// before the delete, so resources adopted since the plan or since an earlier item are never deleted. Items of a job
+// run one after another, so no locking is needed.
+func (v *orphanVerifier) verify(ctx context.Context, item lib.JobItem) (task orphanTask, deletable bool, err error) {
+	if time.Since(v.credentialsAt) > planCredentialsMaxAge {
+		if _, v.token, err = v.cs.GetServiceCredentials(ctx); err != nil {
+			return
+		}
+		v.credentialsAt = time.Now()
+	}
+	task, err = v.cs.getOrphanTask(ctx, item.Kind, v.scope, v.token)
+	if err != nil {
+		return
+	}
+	if task.remove == nil {
+		return task, false, errors.New("delete not supported for " + item.Kind)
+	}
+	entries, err := task.detect()
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.Id == item.Id {
+			return task, entry.Deletable, nil
+		}
+	}
+	return task, false, nil
+}
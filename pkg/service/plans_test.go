@@ -0,0 +1,149 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+)
+
+func newPlanTestService(t *testing.T, driver *fakeDriver) *CleanupService {
+	cs := newTestService(t, driver)
+	enableJobs(t, cs)
+	// services without a live target workload are orphaned
+	driver.services[lib.PIPELINE] = []lib.KubeService{
+		{Id: "pipes:s1", TargetWorkloadIds: []string{"deployment:pipes:op-1"}},
+		{Id: "pipes:s2", TargetWorkloadIds: []string{"deployment:pipes:op-2"}},
+		{Id: "pipes:s3", TargetWorkloadIds: []string{"deployment:pipes:op-3"}},
+	}
+	return cs
+}
+
+// enableJobs adds the job manager, the plan store and the service account cs needs for jobs and plans.
+func enableJobs(t *testing.T, cs *CleanupService) {
+	jobs, err := NewJobManager(context.Background(), filepath.Join(cs.config.DataDir, "jobs"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.jobs = jobs
+	cs.plans = NewPlanStore(filepath.Join(cs.config.DataDir, "plans"))
+	cs.keycloak = *newTestKeycloak(t)
+}
+
+// withGracePeriod makes orphans deletable once they were seen for gracePeriod.
+func withGracePeriod(t *testing.T, cs *CleanupService, gracePeriod time.Duration) {
+	tracker, err := NewOrphanTracker("", gracePeriod, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.tracker = tracker
+}
+
+func readopt(driver *fakeDriver, name string) {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+	driver.workloads[lib.PIPELINE] = append(driver.workloads[lib.PIPELINE], lib.Workload{Id: "deployment:pipes:" + name, Name: name, Type: lib.WorkloadTypeDeployment})
+}
+
+func waitForJob(t *testing.T, cs *CleanupService, id string) lib.Job {
+	for range 100 {
+		job, err := cs.GetJob(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.FinishedAt != nil {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job did not finish")
+	return lib.Job{}
+}
+
+func TestApplyPlanSkipsReadoptedItems(t *testing.T) {
+	driver := newFakeDriver()
+	cs := newPlanTestService(t, driver)
+	ctx := context.Background()
+	plan, err := cs.CreatePlan(ctx, lib.PlanRequest{Kinds: []string{OrphanKindKubeServices}}, "admin", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 3 {
+		t.Fatalf("expected three planned services, got %+v", plan.Items)
+	}
+	// s1 stops being an orphan between plan and apply
+	readopt(driver, "op-1")
+	// s3 stops being an orphan while s2 is deleted, after the apply started
+	driver.onDelete = func(id string) {
+		if id == "pipes:s2" {
+			readopt(driver, "op-3")
+		}
+	}
+	job, err := cs.ApplyPlan(ctx, plan.Id, plan.Hash, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	job = waitForJob(t, cs, job.Id)
+	statuses := map[string]string{}
+	for _, result := range job.Results {
+		statuses[result.Id] = result.Status
+	}
+	expected := map[string]string{"pipes:s1": lib.JobResultSkipped, "pipes:s2": lib.DeleteResultDeleted, "pipes:s3": lib.JobResultSkipped}
+	for id, status := range expected {
+		if statuses[id] != status {
+			t.Errorf("expected %s to be %s, got %s", id, status, statuses[id])
+		}
+	}
+	if !slices.Equal(driver.deleted, []string{"service:pipes:s2"}) {
+		t.Fatalf("unexpected deletes %v", driver.deleted)
+	}
+}
+
+func TestApplyPlanVerifiesInCreatorScope(t *testing.T) {
+	cs, driver := newServingTestService(t)
+	enableJobs(t, cs)
+	withGracePeriod(t, cs, 20*time.Millisecond)
+	ctx := context.Background()
+	// the scans of the creator made the workload deletable, the service account never saw it
+	if _, err := cs.GetOrphanedServingWorkloads(ctx, "admin", "token"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	plan, err := cs.CreatePlan(ctx, lib.PlanRequest{Kinds: []string{OrphanKindServingWorkloads}}, "admin", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 1 {
+		t.Fatalf("expected one planned workload, got %+v", plan.Items)
+	}
+	job, err := cs.ApplyPlan(ctx, plan.Id, plan.Hash, "other-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	job = waitForJob(t, cs, job.Id)
+	if len(job.Results) != 1 || job.Results[0].Status != lib.DeleteResultDeleted {
+		t.Fatalf("expected the planned workload to be deleted, got %+v", job.Results)
+	}
+	if !slices.Equal(driver.deleted, []string{"workload:deployment:serving:kafka2influx-i2"}) {
+		t.Fatalf("unexpected deletes %v", driver.deleted)
+	}
+}
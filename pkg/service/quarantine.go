@@ -0,0 +1,320 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+	"github.com/google/uuid"
+)
+
+// quarantinePurgeInterval is how often entries past their retention are purged.
+const quarantinePurgeInterval = time.Hour
+
+// QuarantineStore persists quarantine entries, one file per entry in dir.
+type QuarantineStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewQuarantineStore(dir string) *QuarantineStore {
+	return &QuarantineStore{dir: dir}
+}
+
+func (s *QuarantineStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// get loads entry id. Callers must hold s.mu.
+func (s *QuarantineStore) get(id string) (entry lib.QuarantineEntry, err error) {
+	// ids are uuids, anything else could point outside of dir
+	if uuid.Validate(id) != nil {
+		return entry, lib.NewNotFoundError(errors.New("quarantine entry " + id + " not found"))
+	}
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return entry, lib.NewNotFoundError(errors.New("quarantine entry " + id + " not found"))
+	}
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &entry)
+	return
+}
+
+// save persists entry. Callers must hold s.mu.
+func (s *QuarantineStore) save(entry lib.QuarantineEntry) (err error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(s.dir, 0755); err != nil {
+		return
+	}
+	path := s.path(entry.Id)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, path)
+}
+
+// list loads all entries, newest first. Callers must hold s.mu.
+func (s *QuarantineStore) list() (entries []lib.QuarantineEntry, err error) {
+	entries = []lib.QuarantineEntry{}
+	files, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		entry, err := s.get(file.Name()[:len(file.Name())-len(".json")])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b lib.QuarantineEntry) int {
+		return b.QuarantinedAt.Compare(a.QuarantinedAt)
+	})
+	return
+}
+
+func (s *QuarantineStore) add(kind string, resourceId string, collection string, spec any, retention time.Duration) (entry lib.QuarantineEntry, err error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+	entry = lib.QuarantineEntry{
+		Id:            uuid.NewString(),
+		Kind:          kind,
+		ResourceId:    resourceId,
+		Collection:    collection,
+		Spec:          data,
+		QuarantinedAt: now,
+		PurgeAt:       now.Add(retention),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = s.save(entry)
+	return
+}
+
+func (s *QuarantineStore) remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return os.Remove(s.path(id))
+}
+
+// quarantineDelete calls remove unless ctx is a dry run, like guardDelete. In quarantine mode the spec returned by
+// export is stored before and dropped again if remove fails, so only deleted resources are listed. Entries of deletes
+// with unknown outcome are kept.
+func (cs *CleanupService) quarantineDelete(ctx context.Context, kind string, id string, collection string, export func() (any, error), remove func() error) error {
+	return cs.guardDelete(ctx, func() error {
+		if !cs.config.Quarantine {
+			return remove()
+		}
+		spec, err := export()
+		if err != nil {
+			return err
+		}
+		entry, err := cs.quarantine.add(kind, id, collection, spec, cs.config.QuarantineRetention)
+		if err != nil {
+			return errors.New("could not quarantine " + kind + " " + id + ": " + err.Error())
+		}
+		if err = remove(); err != nil {
+			var indeterminate *lib.IndeterminateError
+			if errors.As(err, &indeterminate) {
+				// the resource may be gone anyway, keep the spec to restore it
+				return err
+			}
+			if err := cs.quarantine.remove(entry.Id); err != nil {
+				util.Logger.Error("could not drop quarantine entry of failed delete", "entry", entry.Id, "error", err)
+			}
+			return err
+		}
+		util.Logger.Info("quarantined "+kind, "id", id, "entry", entry.Id, "purge", entry.PurgeAt)
+		return nil
+	})
+}
+
+func (cs *CleanupService) deleteWorkload(ctx context.Context, id string, collection string) error {
+	return cs.quarantineDelete(ctx, lib.ResourceKindWorkload, id, collection, func() (any, error) {
+		return cs.driver.ExportWorkload(ctx, id, collection)
+	}, func() error {
+		return cs.driver.DeleteWorkload(ctx, id, collection)
+	})
+}
+
+func (cs *CleanupService) deleteService(ctx context.Context, id string, collection string) error {
+	return cs.quarantineDelete(ctx, lib.ResourceKindService, id, collection, func() (any, error) {
+		return cs.driver.ExportService(ctx, id, collection)
+	}, func() error {
+		return cs.driver.DeleteService(ctx, id, collection)
+	})
+}
+
+func (cs *CleanupService) deleteTopic(ctx context.Context, topic string) error {
+	return cs.quarantineDelete(ctx, lib.ResourceKindTopic, topic, "", func() (any, error) {
+		return cs.kafkaAdmin.DescribeTopic(ctx, topic)
+	}, func() error {
+		return cs.kafkaAdmin.DeleteTopic(ctx, topic)
+	})
+}
+
+// deletePipeline archives pipe before removing it from the registry.
+func (cs *CleanupService) deletePipeline(ctx context.Context, pipe pipeModels.Pipeline, accessToken string) error {
+	return cs.quarantineDelete(ctx, lib.ResourceKindPipeline, pipe.Id, "", func() (any, error) {
+		return pipe, nil
+	}, func() error {
+		return cs.pipeline.DeletePipeline(ctx, pipe.Id, accessToken)
+	})
+}
+
+// deletePipelineById is deletePipeline for callers without the definition, it is only loaded in quarantine mode.
+func (cs *CleanupService) deletePipelineById(ctx context.Context, id string, userId string, accessToken string) error {
+	return cs.quarantineDelete(ctx, lib.ResourceKindPipeline, id, "", func() (any, error) {
+		pipes, err := cs.pipeline.GetPipelines(ctx, userId, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, pipe := range pipes {
+			if pipe.Id == id {
+				return pipe, nil
+			}
+		}
+		return nil, lib.NewNotFoundError(errors.New("pipeline " + id + " not found"))
+	}, func() error {
+		return cs.pipeline.DeletePipeline(ctx, id, accessToken)
+	})
+}
+
+func (cs *CleanupService) GetQuarantine() ([]lib.QuarantineEntry, error) {
+	cs.quarantine.mu.Lock()
+	defer cs.quarantine.mu.Unlock()
+	return cs.quarantine.list()
+}
+
+func (cs *CleanupService) GetQuarantineEntry(id string) (lib.QuarantineEntry, error) {
+	cs.quarantine.mu.Lock()
+	defer cs.quarantine.mu.Unlock()
+	return cs.quarantine.get(id)
+}
+
+// RestoreQuarantined recreates the resource of entry id. Pipelines are recreated in the name of their owner.
+// Entries stay until they are purged, restoring one twice is a conflict.
+func (cs *CleanupService) RestoreQuarantined(ctx context.Context, id string, userId string) (entry lib.QuarantineEntry, err error) {
+	// the lock is held during the restore, so concurrent requests can not restore an entry twice
+	cs.quarantine.mu.Lock()
+	defer cs.quarantine.mu.Unlock()
+	entry, err = cs.quarantine.get(id)
+	if err != nil {
+		return
+	}
+	if entry.RestoredAt != nil {
+		return entry, lib.NewConflictError(errors.New("quarantine entry " + id + " already restored by " + entry.RestoredBy))
+	}
+	switch entry.Kind {
+	case lib.ResourceKindWorkload:
+		err = cs.driver.RestoreWorkload(ctx, entry.Spec, entry.Collection)
+	case lib.ResourceKindService:
+		err = cs.driver.RestoreService(ctx, entry.Spec, entry.Collection)
+	case lib.ResourceKindTopic:
+		var spec lib.TopicSpec
+		if err = json.Unmarshal(entry.Spec, &spec); err != nil {
+			return
+		}
+		err = cs.kafkaAdmin.CreateTopic(ctx, spec)
+	case lib.ResourceKindPipeline:
+		var pipe pipeModels.Pipeline
+		if err = json.Unmarshal(entry.Spec, &pipe); err != nil {
+			return
+		}
+		result := cs.recreatePipeline(ctx, pipe)
+		if result.Status == lib.JobResultSkipped {
+			err = lib.NewConflictError(errors.New(result.Error))
+		} else if result.Status != lib.JobResultRecreated {
+			err = errors.New(result.Error)
+		}
+	default:
+		err = lib.NewInternalError(errors.New("can not restore " + entry.Kind))
+	}
+	if err != nil {
+		return
+	}
+	restored := time.Now().UTC()
+	entry.RestoredAt = &restored
+	entry.RestoredBy = userId
+	util.Logger.Info("restored quarantined "+entry.Kind, "id", entry.ResourceId, "entry", entry.Id, "user", userId)
+	err = cs.quarantine.save(entry)
+	return
+}
+
+// PurgeQuarantine drops all entries past their retention. The resources are deleted already, only the specs to
+// restore them are lost.
+func (cs *CleanupService) PurgeQuarantine() (purged int, err error) {
+	cs.quarantine.mu.Lock()
+	defer cs.quarantine.mu.Unlock()
+	entries, err := cs.quarantine.list()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		if now.Before(entry.PurgeAt) {
+			continue
+		}
+		if err = os.Remove(cs.quarantine.path(entry.Id)); err != nil {
+			return
+		}
+		purged++
+		util.Logger.Info("purged quarantined "+entry.Kind, "id", entry.ResourceId, "entry", entry.Id)
+	}
+	return
+}
+
+// RunQuarantinePurge purges the quarantine right away and then every quarantinePurgeInterval until ctx is done.
+func (cs *CleanupService) RunQuarantinePurge(ctx context.Context) {
+	ticker := time.NewTicker(quarantinePurgeInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := cs.PurgeQuarantine(); err != nil {
+			util.Logger.Error("could not purge quarantine", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	pipeModels "github.com/SENERGY-Platform/analytics-pipeline/lib"
+)
+
+type workloadMatch struct {
+	workload lib.Workload
+	rule     string
+}
+
+// pipelineResources are all resources that belong to one pipeline, whether or not it is still in the registry.
+type pipelineResources struct {
+	id                  string
+	pipe                *pipeModels.Pipeline
+	otherPipes          []pipeModels.Pipeline
+	workloads           []workloadMatch
+	otherWorkloads      []lib.Workload
+	services            []lib.KubeService
+	internalTopics      []string
+	outputTopics        []string
+	missingOutputTopics []string
+	groups              []lib.ConsumerGroup
+}
+
+func (r pipelineResources) empty() bool {
+	return r.pipe == nil && len(r.workloads) == 0 && len(r.services) == 0 && len(r.internalTopics) == 0 && len(r.groups) == 0
+}
+
+// pipelineInventory is a snapshot of the registry, the cluster and kafka, shared when resolving several pipelines.
+type pipelineInventory struct {
+	pipes     []pipeModels.Pipeline
+	workloads []lib.Workload
+	services  []lib.KubeService
+	topics    []string
+	groups    []string
+}
+
+func (cs *CleanupService) loadPipelineInventory(ctx context.Context, userId string, authToken string) (inv pipelineInventory, err error) {
+	inv.pipes, err = cs.pipeline.GetPipelines(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	inv.workloads, err = cs.driver.GetWorkloads(ctx, lib.PIPELINE)
+	if err != nil {
+		return
+	}
+	inv.services, err = cs.driver.GetServices(ctx, lib.PIPELINE)
+	if err != nil {
+		return
+	}
+	inv.topics, err = cs.kafkaAdmin.GetTopics(ctx)
+	if err != nil {
+		return
+	}
+	inv.groups, err = cs.kafkaAdmin.ListConsumerGroups(ctx)
+	return
+}
+
+// resolvePipelineResources collects the registry entry, workloads, services, topics and consumer groups of pipeline id.
+func (cs *CleanupService) resolvePipelineResources(ctx context.Context, inv pipelineInventory, id string) (res pipelineResources, err error) {
+	res.id = id
+	pipe := pipeModels.Pipeline{Id: id}
+	for i := range inv.pipes {
+		if inv.pipes[i].Id == id {
+			res.pipe = &inv.pipes[i]
+			pipe = inv.pipes[i]
+		} else {
+			res.otherPipes = append(res.otherPipes, inv.pipes[i])
+		}
+	}
+	var pipeWorkloads []lib.Workload
+	for _, workload := range inv.workloads {
+		if rule, ok := cs.ownership.Match(workload, pipe); ok {
+			res.workloads = append(res.workloads, workloadMatch{workload: workload, rule: rule})
+			pipeWorkloads = append(pipeWorkloads, workload)
+		} else {
+			res.otherWorkloads = append(res.otherWorkloads, workload)
+		}
+	}
+	for _, service := range inv.services {
+		if serviceInWorkloads(service, pipeWorkloads) {
+			res.services = append(res.services, service)
+		}
+	}
+	existing := map[string]bool{}
+	for _, topic := range inv.topics {
+		existing[topic] = true
+		if isInternalAnalyticsTopic(topic) && getTopicPipelineId(topic) == id {
+			res.internalTopics = append(res.internalTopics, topic)
+		}
+	}
+	for _, operator := range pipe.Operators {
+		if operator.OutputTopic == "" {
+			continue
+		}
+		if existing[operator.OutputTopic] {
+			res.outputTopics = append(res.outputTopics, operator.OutputTopic)
+		} else {
+			res.missingOutputTopics = append(res.missingOutputTopics, operator.OutputTopic)
+		}
+	}
+	var pipeGroups []string
+	for _, group := range inv.groups {
+		if getTopicPipelineId(group) == id {
+			pipeGroups = append(pipeGroups, group)
+		}
+	}
+	if len(pipeGroups) > 0 {
+		res.groups, err = cs.kafkaAdmin.DescribeConsumerGroups(ctx, pipeGroups)
+	}
+	return
+}
+
+// GetPipelineResources assembles the resource graph of pipeline id. Edges are marked missing if the pipeline
+// references something that does not exist, and dangling if a resource outlived the registry entry.
+func (cs *CleanupService) GetPipelineResources(ctx context.Context, id string, userId string, authToken string) (graph lib.ResourceGraph, err error) {
+	inv, err := cs.loadPipelineInventory(ctx, userId, authToken)
+	if err != nil {
+		return
+	}
+	res, err := cs.resolvePipelineResources(ctx, inv, id)
+	if err != nil {
+		return
+	}
+	if res.empty() {
+		return graph, lib.NewNotFoundError(errors.New("pipeline " + id + " not found"))
+	}
+	graph = lib.ResourceGraph{PipelineId: id}
+	var pipeKey string
+	// resources of a pipeline that is gone from the registry are dangling
+	edgeStatus := lib.ResourceStatusOk
+	if res.pipe == nil {
+		edgeStatus = lib.ResourceStatusDangling
+		pipeKey = graph.AddNode(lib.ResourceKindPipeline, id, lib.ResourceStatusMissing, nil)
+	} else {
+		pipeKey = graph.AddNode(lib.ResourceKindPipeline, id, lib.ResourceStatusOk, res.pipe)
+
+		reason, err := cs.getOwnerlessReason(ctx, res.pipe.UserId)
+		if err != nil {
+			return graph, err
+		}
+		ownerStatus := lib.ResourceStatusOk
+		if reason == lib.OwnerlessReasonUserNotFound {
+			ownerStatus = lib.ResourceStatusMissing
+		}
+		ownerKey := graph.AddNode(lib.ResourceKindOwner, res.pipe.UserId, ownerStatus, nil)
+		graph.AddEdge(pipeKey, ownerKey, ownerStatus, reason)
+
+		if res.pipe.FlowId != "" {
+			missing, err := cs.flowMissing(ctx, res.pipe.FlowId, res.pipe.UserId, authToken)
+			if err != nil {
+				return graph, err
+			}
+			flowStatus := lib.ResourceStatusOk
+			if missing {
+				flowStatus = lib.ResourceStatusMissing
+			}
+			flowKey := graph.AddNode(lib.ResourceKindFlow, res.pipe.FlowId, flowStatus, nil)
+			graph.AddEdge(pipeKey, flowKey, flowStatus, "")
+		}
+
+		if len(res.workloads) == 0 && !isLocalPipeline(*res.pipe) {
+			workloadKey := graph.AddNode(lib.ResourceKindWorkload, "", lib.ResourceStatusMissing, nil)
+			graph.AddEdge(pipeKey, workloadKey, lib.ResourceStatusMissing, "no workload matches the ownership rules")
+		}
+	}
+	for _, match := range res.workloads {
+		workloadKey := graph.AddNode(lib.ResourceKindWorkload, match.workload.Id, lib.ResourceStatusOk, match.workload)
+		graph.AddEdge(pipeKey, workloadKey, edgeStatus, "matched by "+match.rule)
+	}
+	for _, service := range res.services {
+		serviceKey := graph.AddNode(lib.ResourceKindService, service.Id, lib.ResourceStatusOk, service)
+		for _, match := range res.workloads {
+			if serviceInWorkloads(service, []lib.Workload{match.workload}) {
+				graph.AddEdge(lib.ResourceKindWorkload+"/"+match.workload.Id, serviceKey, edgeStatus, "")
+			}
+		}
+	}
+	for _, topic := range res.internalTopics {
+		topicKey := graph.AddNode(lib.ResourceKindTopic, topic, lib.ResourceStatusOk, nil)
+		graph.AddEdge(pipeKey, topicKey, edgeStatus, "internal topic")
+	}
+	for _, topic := range res.outputTopics {
+		topicKey := graph.AddNode(lib.ResourceKindTopic, topic, lib.ResourceStatusOk, nil)
+		graph.AddEdge(pipeKey, topicKey, edgeStatus, "output topic")
+	}
+	for _, topic := range res.missingOutputTopics {
+		topicKey := graph.AddNode(lib.ResourceKindTopic, topic, lib.ResourceStatusMissing, nil)
+		graph.AddEdge(pipeKey, topicKey, lib.ResourceStatusMissing, "output topic")
+	}
+	for _, group := range res.groups {
+		groupKey := graph.AddNode(lib.ResourceKindConsumerGroup, group.Id, lib.ResourceStatusOk, group)
+		graph.AddEdge(pipeKey, groupKey, edgeStatus, strconv.Itoa(group.Members)+" members")
+	}
+	return
+}
+
+func isLocalPipeline(pipe pipeModels.Pipeline) bool {
+	for _, operator := range pipe.Operators {
+		if operator.DeploymentType == "local" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/config"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/util"
+	"github.com/robfig/cron/v3"
+)
+
+type schedulerState struct {
+	status lib.SchedulerStatus
+	mu     sync.Mutex
+}
+
+// RunScheduler runs the configured tasks on every tick of Config.CronSchedule until ctx is done.
+// Runs are executed one after another, ticks that pass while a run is still in progress are skipped.
+func (cs *CleanupService) RunScheduler(ctx context.Context) (err error) {
+	schedule, err := cron.ParseStandard(cs.config.CronSchedule)
+	if err != nil {
+		return errors.New("invalid cron schedule " + cs.config.CronSchedule + ": " + err.Error())
+	}
+	tasks, err := cs.getScheduledTasks(cs.config.ScheduledTasks)
+	if err != nil {
+		return
+	}
+	s := &cs.scheduler
+	s.mu.Lock()
+	s.status.Schedule = cs.config.CronSchedule
+	s.mu.Unlock()
+	util.Logger.Info("starting scheduler", "schedule", cs.config.CronSchedule)
+	for {
+		next := schedule.Next(time.Now())
+		s.mu.Lock()
+		s.status.NextRun = &next
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			util.Logger.Info("scheduler stopped")
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+		cs.runScheduledTasks(ctx, tasks)
+	}
+}
+
+// GetSchedulerStatus returns the schedule and the results of the last run. It is served in web and in cron mode.
+func (cs *CleanupService) GetSchedulerStatus() lib.SchedulerStatus {
+	s := &cs.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Results = slices.Clone(s.status.Results)
+	return status
+}
+
+func (cs *CleanupService) getScheduledTasks(tasks []config.ScheduledTaskConfig) ([]config.ScheduledTaskConfig, error) {
+	if len(tasks) == 0 {
+		for _, kind := range OrphanKinds {
+			tasks = append(tasks, config.ScheduledTaskConfig{Kind: kind})
+		}
+	}
+	for _, task := range tasks {
+		orphanTask, err := cs.getOrphanTask(context.Background(), task.Kind, "", "")
+		if err != nil {
+			return nil, errors.New("invalid scheduled task: " + err.Error())
+		}
+		if task.Delete && orphanTask.remove == nil {
+			return nil, errors.New("scheduled task kind " + task.Kind + " does not support delete")
+		}
+	}
+	return tasks, nil
+}
+
+func (cs *CleanupService) runScheduledTasks(ctx context.Context, tasks []config.ScheduledTaskConfig) {
+	s := &cs.scheduler
+	start := time.Now()
+	s.mu.Lock()
+	s.status.Running = true
+	s.status.LastRun = &start
+	s.mu.Unlock()
+
+	var results []lib.ScheduledTaskResult
+	userId, token, err := cs.GetServiceCredentials(ctx)
+	for _, task := range tasks {
+		var result lib.ScheduledTaskResult
+		if err != nil {
+			result = lib.ScheduledTaskResult{Kind: task.Kind, Delete: task.Delete, Error: err.Error()}
+		} else {
+			result = cs.RunTask(ctx, task, userId, token)
+		}
+		// the status only keeps the last run, the log keeps them all
+		if result.Error != "" {
+			util.Logger.Error("scheduled task failed", "kind", result.Kind, "error", result.Error)
+		} else {
+			util.Logger.Info("scheduled task finished", "kind", result.Kind, "delete", result.Delete, "found", result.Found, "deletable", result.Deletable, "deleted", result.Deleted)
+		}
+		results = append(results, result)
+	}
+
+	finished := time.Now()
+	s.mu.Lock()
+	s.status.Running = false
+	s.status.LastFinished = &finished
+	s.status.Results = results
+	s.mu.Unlock()
+	util.Logger.Info("scheduled run finished", "duration", finished.Sub(start).String())
+}
+
+// GetServiceCredentials returns the user id and token of the keycloak service account for runs without a request.
+func (cs *CleanupService) GetServiceCredentials(ctx context.Context) (userId string, token string, err error) {
+	token, err = cs.keycloak.GetAccessToken(ctx)
+	if err != nil {
+		return
+	}
+	info, err := cs.keycloak.GetUserInfo(ctx)
+	if err != nil {
+		return
+	}
+	if info.Sub == nil {
+		return "", "", errors.New("service account has no subject")
+	}
+	return *info.Sub, token, nil
+}
+
+// RunTask detects the orphans of task.Kind and deletes the ones that passed the grace period if task.Delete is set.
+func (cs *CleanupService) RunTask(ctx context.Context, task config.ScheduledTaskConfig, userId string, token string) (result lib.ScheduledTaskResult) {
+	result = lib.ScheduledTaskResult{Kind: task.Kind, Delete: task.Delete}
+	var entries []lib.OrphanEntry
+	var results []lib.DeleteResult
+	var err error
+	if task.Delete {
+		entries, results, err = cs.DeleteOrphans(ctx, task.Kind, userId, token)
+	} else {
+		entries, err = cs.DetectOrphans(ctx, task.Kind, userId, token)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	result.Found = len(entries)
+	for _, entry := range entries {
+		if entry.Deletable {
+			result.Deletable++
+		}
+	}
+	for _, r := range results {
+		// dry runs leave Deleted at zero
+		if r.Status == lib.DeleteResultDeleted || r.Status == lib.DeleteResultNotFound {
+			result.Deleted++
+		}
+	}
+	if err = failedDeletes(results); err != nil {
+		result.Error = err.Error()
+	}
+	return
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2025 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/SENERGY-Platform/analytics-cleanup/lib"
+	"github.com/SENERGY-Platform/analytics-cleanup/pkg/apis"
+)
+
+func newServingTestService(t *testing.T) (*CleanupService, *fakeDriver) {
+	driver := newFakeDriver()
+	driver.workloads[lib.SERVING] = []lib.Workload{
+		{Id: "deployment:serving:kafka2influx-i1", Name: "kafka2influx-i1", Type: lib.WorkloadTypeDeployment},
+		{Id: "deployment:serving:kafka2influx-i2", Name: "kafka2influx-i2", Type: lib.WorkloadTypeDeployment},
+	}
+	driver.services[lib.SERVING] = []lib.KubeService{
+		{Id: "serving:i1", Name: "i1", TargetWorkloadIds: []string{"deployment:serving:kafka2influx-i1"}},
+		{Id: "serving:i2", Name: "i2", TargetWorkloadIds: []string{"deployment:serving:kafka2influx-i2"}},
+	}
+	// a pipeline workload with the same name must not be mistaken for a serving workload
+	driver.workloads[lib.PIPELINE] = []lib.Workload{{Id: "deployment:pipes:kafka2influx-i2", Name: "kafka2influx-i2"}}
+	server := newTestServer(t, map[string]any{
+		"GET /admin/instance": lib.ServingInstancesResponse{Total: 1, Count: 1, Data: []lib.ServingInstance{{Id: "i1"}}},
+	})
+	cs := newTestService(t, driver)
+	cs.serving = *apis.NewServingService(server.URL, 0)
+	return cs, driver
+}
+
+func TestGetOrphanedServingWorkloads(t *testing.T) {
+	cs, _ := newServingTestService(t)
+	orphans, err := cs.GetOrphanedServingWorkloads(context.Background(), "user", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 1 || orphans[0].Resource.Name != "kafka2influx-i2" {
+		t.Fatalf("unexpected orphans %+v", orphans)
+	}
+}
+
+func TestGetOrphanedServingKubeServices(t *testing.T) {
+	cs, _ := newServingTestService(t)
+	orphans, err := cs.GetOrphanedServingKubeServices(context.Background(), "user", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 1 || orphans[0].Resource.Id != "serving:i2" {
+		t.Fatalf("unexpected orphans %+v", orphans)
+	}
+}
+
+func TestDeleteOrphanedServingWorkload(t *testing.T) {
+	cs, driver := newServingTestService(t)
+	if err := cs.DeleteOrphanedServingWorkload(context.Background(), "kafka2influx-i2"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(driver.deleted, []string{"workload:deployment:serving:kafka2influx-i2"}) {
+		t.Fatalf("unexpected deletes %v", driver.deleted)
+	}
+	if len(driver.workloads[lib.PIPELINE]) != 1 {
+		t.Fatal("pipeline workload deleted")
+	}
+}
+
+func TestServingRegistryUnavailable(t *testing.T) {
+	cs, _ := newServingTestService(t)
+	server := newTestServer(t, map[string]any{"GET /admin/instance": http.StatusBadGateway})
+	cs.serving = *apis.NewServingService(server.URL, 0)
+	// without the registry every workload would look orphaned
+	if _, err := cs.GetOrphanedServingWorkloads(context.Background(), "user", "token"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := cs.GetOrphanedServingKubeServices(context.Background(), "user", "token"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -17,8 +17,8 @@
 package util
 
 import (
+	"io"
 	"log/slog"
-	"os"
 	"runtime/debug"
 	"strings"
 	"time"
@@ -28,7 +28,7 @@ import (
 
 var Logger *slog.Logger
 
-func InitStructLogger(level string) {
+func InitStructLogger(level string, out io.Writer) {
 	if Logger == nil {
 		info, ok := debug.ReadBuildInfo()
 		project := "cleanup-service"
@@ -47,7 +47,7 @@ func InitStructLogger(level string) {
 				TimeUtc:    true,
 				AddMeta:    true,
 			},
-			os.Stdout,
+			out,
 			org,
 			project,
 		)